@@ -0,0 +1,491 @@
+// Package generator 按 xhash 的规则为结构体生成 ToRedisHash / FromRedisHash 方法，供 cmd/xhashgen 使用
+// 字段的分析直接使用 xhash.DescribeStruct，保证与反射转换的规则一致
+package generator
+
+import (
+	"bytes"
+	"encoding"
+	"fmt"
+	"github.com/pkg/errors"
+	"github.com/wanghuida/go-redis-ext/xredis/xhash"
+	"go/format"
+	"google.golang.org/protobuf/proto"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const xhashPath = "github.com/wanghuida/go-redis-ext/xredis/xhash"
+
+var (
+	timeType            = reflect.TypeOf(time.Time{})
+	stringType          = reflect.TypeOf("")
+	marshalerType       = reflect.TypeOf((*xhash.Marshaler)(nil)).Elem()
+	unmarshalerType     = reflect.TypeOf((*xhash.Unmarshaler)(nil)).Elem()
+	textMarshalerType   = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+	protoMessageType    = reflect.TypeOf((*proto.Message)(nil)).Elem()
+)
+
+// generator 生成一个文件
+type generator struct {
+	pkgPath string
+	pkgName string
+	imports map[string]string // 导入的包，path -> 名称
+	used    map[string]bool   // 已使用的包名称
+	vars    []string          // 包级别的变量
+	current reflect.Type      // 正在生成的结构体
+	body    bytes.Buffer
+}
+
+// Generate 为 pkgPath 包中的结构体生成方法，返回格式化后的代码
+func Generate(pkgPath, pkgName string, types ...reflect.Type) ([]byte, error) {
+	g := &generator{
+		pkgPath: pkgPath,
+		pkgName: pkgName,
+		imports: map[string]string{xhashPath: "xhash"},
+		used:    map[string]bool{"xhash": true},
+	}
+	for _, t := range types {
+		if err := g.generateType(t); err != nil {
+			return nil, errors.Wrapf(err, "type=%s", t)
+		}
+	}
+
+	var file bytes.Buffer
+	file.WriteString("// Code generated by xhashgen. DO NOT EDIT.\n\n")
+	fmt.Fprintf(&file, "package %s\n\n", pkgName)
+	file.WriteString("import (\n")
+	paths := make([]string, 0, len(g.imports))
+	for path := range g.imports {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		name := g.imports[path]
+		if name == path[strings.LastIndex(path, "/")+1:] {
+			fmt.Fprintf(&file, "\t%q\n", path)
+		} else {
+			fmt.Fprintf(&file, "\t%s %q\n", name, path)
+		}
+	}
+	file.WriteString(")\n\n")
+	if len(g.vars) > 0 {
+		file.WriteString("var (\n")
+		for _, v := range g.vars {
+			file.WriteString("\t" + v + "\n")
+		}
+		file.WriteString(")\n\n")
+	}
+	file.Write(g.body.Bytes())
+
+	code, err := format.Source(file.Bytes())
+	if err != nil {
+		return nil, errors.Wrap(err, "format generated code")
+	}
+	return code, nil
+}
+
+// field 需要生成的字段
+type field struct {
+	meta   xhash.FieldMeta
+	typ    reflect.Type
+	expr   string     // 字段的访问表达式，例: m.Base.Id
+	guards []guardPtr // 路径上的内嵌指针
+}
+
+// guardPtr 路径上的内嵌指针，读取时为 nil 跳过，写入时分配空间
+type guardPtr struct {
+	expr string
+	elem reflect.Type
+}
+
+func (g *generator) generateType(t reflect.Type) error {
+	if t.Kind() != reflect.Struct || t.Name() == "" {
+		return errors.New("only named struct types are supported")
+	}
+	if t.PkgPath() != g.pkgPath {
+		errMsg := fmt.Sprintf("type is not in package %s", g.pkgPath)
+		return errors.New(errMsg)
+	}
+	g.current = t
+	metas, err := xhash.DescribeStruct(t)
+	if err != nil {
+		return err
+	}
+
+	fields := make([]*field, 0, len(metas))
+	for _, meta := range metas {
+		f := &field{meta: meta, expr: "m"}
+		current := t
+		for i, x := range meta.Index {
+			structField := current.Field(x)
+			f.expr += "." + structField.Name
+			f.typ = structField.Type
+			if i == len(meta.Index)-1 {
+				break
+			}
+			current = structField.Type
+			if current.Kind() == reflect.Ptr {
+				current = current.Elem()
+				f.guards = append(f.guards, guardPtr{expr: f.expr, elem: current})
+			}
+		}
+		fields = append(fields, f)
+	}
+
+	if err := g.generateEncode(t, fields); err != nil {
+		return err
+	}
+	return g.generateDecode(t, fields)
+}
+
+// ----------------------------------------
+// 模型转 map
+// ----------------------------------------
+func (g *generator) generateEncode(t reflect.Type, fields []*field) error {
+	w := &g.body
+	fmt.Fprintf(w, "// ToRedisHash 模型转 map，由 xhashgen 生成\n")
+	fmt.Fprintf(w, "func (m *%s) ToRedisHash() (map[string]interface{}, error) {\n", t.Name())
+	fmt.Fprintf(w, "result := make(map[string]interface{}, %d)\n", len(fields))
+	for _, f := range fields {
+		closes := 0
+		// 内嵌的结构体指针为 nil 时，其中的字段都不存储
+		if len(f.guards) > 0 {
+			conds := make([]string, 0, len(f.guards))
+			for _, guard := range f.guards {
+				conds = append(conds, guard.expr+" != nil")
+			}
+			fmt.Fprintf(w, "if %s {\n", strings.Join(conds, " && "))
+			closes++
+		}
+		// 零值不存储
+		if f.meta.Tag.OmitEmpty {
+			if empty := emptyExpr(f.expr, f.typ); empty != "" {
+				fmt.Fprintf(w, "if !(%s) {\n", empty)
+				closes++
+			}
+		}
+		if err := g.encodeValue(f, f.expr, f.typ); err != nil {
+			return err
+		}
+		w.WriteString(strings.Repeat("}\n", closes))
+	}
+	w.WriteString("return result, nil\n}\n\n")
+	return nil
+}
+
+func (g *generator) encodeValue(f *field, expr string, t reflect.Type) error {
+	w := &g.body
+	key := strconv.Quote(f.meta.Name)
+	set := func(value string) {
+		fmt.Fprintf(w, "result[%s] = %s\n", key, value)
+	}
+	call := func(call, value string) {
+		fmt.Fprintf(w, "if v, e := %s; e != nil {\nreturn nil, e\n} else {\nresult[%s] = %s\n}\n", call, key, value)
+	}
+
+	if t.Kind() == reflect.Ptr {
+		fmt.Fprintf(w, "if %s == nil {\n", expr)
+		set("nil")
+		w.WriteString("} else {\n")
+		if err := g.encodeValue(f, "(*"+expr+")", t.Elem()); err != nil {
+			return err
+		}
+		w.WriteString("}\n")
+		return nil
+	}
+	if implements(t, marshalerType) {
+		call(expr+".MarshalRedisField()", "v")
+		return nil
+	}
+	if t == timeType {
+		call(g.timeCodec(f)+".Encode("+expr+")", "v")
+		return nil
+	}
+	if implements(t, textMarshalerType) {
+		call(expr+".MarshalText()", "string(v)")
+		return nil
+	}
+
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		set("int64(" + expr + ")")
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		set("uint64(" + expr + ")")
+	case reflect.String:
+		set(expr)
+	case reflect.Bool:
+		set("bool(" + expr + ")")
+	case reflect.Float32, reflect.Float64:
+		set("float64(" + expr + ")")
+	case reflect.Slice, reflect.Map, reflect.Struct:
+		// protobuf 的消息需要传入指针，与反射转换一致
+		if isProtoMessage(t) {
+			expr = "&" + expr
+		}
+		if f.meta.Codec == "" {
+			call("xhash.JSONCodec.Marshal("+expr+")", "v")
+		} else {
+			call(fmt.Sprintf("xhash.MarshalNested(%q, %q, %s)", f.meta.Name, f.meta.Codec, expr), "v")
+		}
+	case reflect.Interface:
+		if !stringType.AssignableTo(t) {
+			return unsupported(f, t)
+		}
+		set(expr)
+	default:
+		return unsupported(f, t)
+	}
+	return nil
+}
+
+// emptyExpr 零值判断的表达式，规则同 xhash 的 omitempty，不会为空的类型返回空字符串
+func emptyExpr(expr string, t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return "len(" + expr + ") == 0"
+	case reflect.Bool:
+		return "!" + expr
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64:
+		return expr + " == 0"
+	case reflect.Interface, reflect.Ptr:
+		return expr + " == nil"
+	case reflect.Struct:
+		if t == timeType {
+			return expr + ".IsZero()"
+		}
+	}
+	return ""
+}
+
+// ----------------------------------------
+// map 转模型
+// ----------------------------------------
+func (g *generator) generateDecode(t reflect.Type, fields []*field) error {
+	w := &g.body
+	fmt.Fprintf(w, "// FromRedisHash map 转模型，由 xhashgen 生成\n")
+	fmt.Fprintf(w, "func (m *%s) FromRedisHash(origin map[string]string) error {\n", t.Name())
+	for _, f := range fields {
+		key := strconv.Quote(f.meta.Name)
+		switch {
+		case f.meta.Tag.Required:
+			fmt.Fprintf(w, "if v, ok := origin[%s]; ok {\n", key)
+		case f.meta.Tag.HasDefault:
+			fmt.Fprintf(w, "{\nv, ok := origin[%s]\nif !ok {\nv = %s\n}\n", key, strconv.Quote(f.meta.Tag.Default))
+		default:
+			fmt.Fprintf(w, "if v, ok := origin[%s]; ok {\n", key)
+		}
+
+		// 内嵌的结构体指针为 nil 时会分配空间
+		for _, guard := range f.guards {
+			elem, err := g.typeExpr(guard.elem)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(w, "if %s == nil {\n%s = new(%s)\n}\n", guard.expr, guard.expr, elem)
+		}
+		w.WriteString("var err error\n")
+		if err := g.decodeValue(f, f.expr, f.typ, 0); err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "if err != nil {\nreturn &xhash.FieldError{Struct: %q, Field: %q, Key: %s, Value: v, Err: err}\n}\n",
+			t.String(), f.meta.Path, key)
+
+		if f.meta.Tag.Required {
+			fmt.Fprintf(w, "} else {\nreturn &xhash.FieldError{Struct: %q, Field: %q, Key: %s, Err: xhash.ErrRequired}\n}\n",
+				t.String(), f.meta.Path, key)
+		} else {
+			w.WriteString("}\n")
+		}
+	}
+	w.WriteString("return nil\n}\n\n")
+	return nil
+}
+
+func (g *generator) decodeValue(f *field, target string, t reflect.Type, depth int) error {
+	w := &g.body
+	parse := func(call, value string) {
+		fmt.Fprintf(w, "if x, e := %s; e != nil {\nerr = e\n} else {\n%s = %s\n}\n", call, target, value)
+	}
+
+	if t.Kind() == reflect.Ptr {
+		elem, err := g.typeExpr(t.Elem())
+		if err != nil {
+			return err
+		}
+		ptr := fmt.Sprintf("p%d", depth)
+		fmt.Fprintf(w, "{\n%s := new(%s)\n", ptr, elem)
+		if err := g.decodeValue(f, "(*"+ptr+")", t.Elem(), depth+1); err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "if err == nil {\n%s = %s\n}\n}\n", target, ptr)
+		return nil
+	}
+	if implements(t, unmarshalerType) {
+		fmt.Fprintf(w, "err = %s.UnmarshalRedisField(v)\n", target)
+		return nil
+	}
+	if t == timeType {
+		parse(g.timeCodec(f)+".Decode(v)", "x")
+		return nil
+	}
+	if implements(t, textUnmarshalerType) {
+		fmt.Fprintf(w, "err = %s.UnmarshalText([]byte(v))\n", target)
+		return nil
+	}
+
+	typeName, err := g.typeExpr(t)
+	if err != nil {
+		return err
+	}
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		parse(fmt.Sprintf("xhash.ParseIntField(v, %s, %q)", g.bits(t), t.String()), typeName+"(x)")
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		parse(fmt.Sprintf("xhash.ParseUintField(v, %s, %q)", g.bits(t), t.String()), typeName+"(x)")
+	case reflect.Float32, reflect.Float64:
+		parse(fmt.Sprintf("xhash.ParseFloatField(v, %d, %q)", t.Bits(), t.String()), typeName+"(x)")
+	case reflect.String:
+		fmt.Fprintf(w, "%s = %s(v)\n", target, typeName)
+	case reflect.Bool:
+		g.addImport("strconv")
+		parse("strconv.ParseBool(v)", typeName+"(x)")
+	case reflect.Slice, reflect.Map, reflect.Struct:
+		// 切片按元素类型的切片解析，与反射转换一致
+		valueType := typeName
+		if t.Kind() == reflect.Slice {
+			elem, err := g.typeExpr(t.Elem())
+			if err != nil {
+				return err
+			}
+			valueType = "[]" + elem
+		}
+		// protobuf 的消息不能复制，直接解析到字段中
+		if isProtoMessage(t) {
+			fmt.Fprintf(w, "err = xhash.UnmarshalNested(%q, %q, []byte(v), &%s)\n", f.meta.Name, f.meta.Codec, target)
+			break
+		}
+		unmarshal := "xhash.JSONCodec.Unmarshal([]byte(v), &x)"
+		if f.meta.Codec != "" {
+			unmarshal = fmt.Sprintf("xhash.UnmarshalNested(%q, %q, []byte(v), &x)", f.meta.Name, f.meta.Codec)
+		}
+		fmt.Fprintf(w, "{\nvar x %s\nif e := %s; e != nil {\nerr = e\n} else {\n%s = x\n}\n}\n",
+			valueType, unmarshal, target)
+	case reflect.Interface:
+		if !stringType.AssignableTo(t) {
+			return unsupported(f, t)
+		}
+		fmt.Fprintf(w, "%s = v\n", target)
+	default:
+		return unsupported(f, t)
+	}
+	return nil
+}
+
+// ----------------------------------------
+// 辅助方法
+// ----------------------------------------
+
+// timeCodec 时间字段使用的包级别变量，按字段的 tag 创建
+func (g *generator) timeCodec(f *field) string {
+	name := "xhash" + g.current.Name() + strings.Replace(f.meta.Path, ".", "", -1) + "Time"
+	for _, v := range g.vars {
+		if strings.HasPrefix(v, name+" ") {
+			return name
+		}
+	}
+	g.vars = append(g.vars, fmt.Sprintf("%s = xhash.NewTimeCodec(%q, %q, %q)",
+		name, f.meta.Name, f.meta.Tag.TimeFormat, f.meta.Tag.Location))
+	return name
+}
+
+// bits int / uint 的位数与平台有关
+func (g *generator) bits(t reflect.Type) string {
+	if t.Kind() == reflect.Int || t.Kind() == reflect.Uint {
+		g.addImport("strconv")
+		return "strconv.IntSize"
+	}
+	return strconv.Itoa(t.Bits())
+}
+
+// typeExpr 类型在生成的代码中的写法，其他包的类型会自动导入
+func (g *generator) typeExpr(t reflect.Type) (string, error) {
+	if t.Name() != "" {
+		if t.PkgPath() == "" || t.PkgPath() == g.pkgPath {
+			return t.Name(), nil
+		}
+		if strings.ContainsAny(t.Name(), "[]") || !isExported(t.Name()) {
+			errMsg := fmt.Sprintf("unsupported type %s", t)
+			return "", errors.New(errMsg)
+		}
+		name := t.String()[:strings.LastIndex(t.String(), ".")]
+		return g.importAs(t.PkgPath(), name) + "." + t.Name(), nil
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr:
+		elem, err := g.typeExpr(t.Elem())
+		return "*" + elem, err
+	case reflect.Slice:
+		elem, err := g.typeExpr(t.Elem())
+		return "[]" + elem, err
+	case reflect.Array:
+		elem, err := g.typeExpr(t.Elem())
+		return fmt.Sprintf("[%d]%s", t.Len(), elem), err
+	case reflect.Map:
+		key, err := g.typeExpr(t.Key())
+		if err != nil {
+			return "", err
+		}
+		elem, err := g.typeExpr(t.Elem())
+		return "map[" + key + "]" + elem, err
+	case reflect.Interface:
+		if t.NumMethod() == 0 {
+			return "interface{}", nil
+		}
+	}
+	errMsg := fmt.Sprintf("unsupported type %s", t)
+	return "", errors.New(errMsg)
+}
+
+func (g *generator) addImport(path string) {
+	g.importAs(path, path[strings.LastIndex(path, "/")+1:])
+}
+
+// importAs 导入包，名称冲突时加上数字后缀
+func (g *generator) importAs(path, name string) string {
+	if alias, ok := g.imports[path]; ok {
+		return alias
+	}
+	alias := name
+	for i := 2; g.used[alias]; i++ {
+		alias = fmt.Sprintf("%s%d", name, i)
+	}
+	g.imports[path] = alias
+	g.used[alias] = true
+	return alias
+}
+
+func unsupported(f *field, t reflect.Type) error {
+	errMsg := fmt.Sprintf("unsupported type name=%s type=%s", f.meta.Path, t)
+	return errors.New(errMsg)
+}
+
+// implements 类型本身或其指针实现了接口，与 xhash 一致
+func implements(t reflect.Type, iface reflect.Type) bool {
+	return t.Implements(iface) || reflect.PtrTo(t).Implements(iface)
+}
+
+// isProtoMessage 结构体的指针是否实现了 proto.Message
+func isProtoMessage(t reflect.Type) bool {
+	return t.Kind() == reflect.Struct && reflect.PtrTo(t).Implements(protoMessageType)
+}
+
+func isExported(name string) bool {
+	return name != "" && strings.ToUpper(name[:1]) == name[:1]
+}
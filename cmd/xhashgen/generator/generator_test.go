@@ -0,0 +1,136 @@
+package generator
+
+import (
+	"github.com/wanghuida/go-redis-ext/demo/model"
+	"github.com/wanghuida/go-redis-ext/xredis/xhash"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+const modelPath = "github.com/wanghuida/go-redis-ext/demo/model"
+
+// TestGolden demo 中提交的生成文件需要与当前的生成结果一致
+func TestGolden(t *testing.T) {
+	code, err := Generate(modelPath, "model", reflect.TypeOf(model.User{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	golden, err := os.ReadFile("../../../demo/model/user_xhash.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(code) != string(golden) {
+		t.Errorf("demo/model/user_xhash.go is out of date, run go generate ./demo/model")
+	}
+}
+
+// TestEquivalence 生成的方法与反射转换的结果一致
+func TestEquivalence(t *testing.T) {
+	redPacketId := int64(100)
+	updatedAt := time.Date(2020, 1, 2, 3, 4, 5, 0, time.Local)
+	users := []*model.User{
+		{},
+		{
+			Id:          1,
+			RedPacketId: &redPacketId,
+			Name:        "hello",
+			Tags:        []string{"a", "b"},
+			Status:      model.UserStatusValid,
+			IsNew:       true,
+			Score:       1.5,
+			Friends:     map[int64]model.UserInfo{2: {Id: 2, Nickname: "world"}},
+			Info:        &model.UserInfo{Id: 1, Nickname: "hello"},
+			CreatedAt:   time.Date(2020, 1, 1, 0, 0, 0, 0, time.Local),
+			UpdatedAt:   &updatedAt,
+			Ignore:      "ignore",
+		},
+	}
+	reflective := xhash.NewEncoder(xhash.Options{NoGenerated: true})
+	reflectiveDecoder := xhash.NewDecoder(xhash.Options{NoGenerated: true})
+	for _, user := range users {
+		generated, err := user.ToRedisHash()
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected, err := reflective.Encode(user)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(generated, expected) {
+			t.Errorf("encode not equal generated=%v reflective=%v", generated, expected)
+		}
+
+		snapshot, err := reflective.Snapshot(user)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var generatedUser, reflectiveUser model.User
+		if err := generatedUser.FromRedisHash(snapshot); err != nil {
+			t.Fatal(err)
+		}
+		if err := reflectiveDecoder.Decode(snapshot, &reflectiveUser); err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(generatedUser, reflectiveUser) {
+			t.Errorf("decode not equal generated=%+v reflective=%+v", generatedUser, reflectiveUser)
+		}
+	}
+
+	// 解析错误与反射转换一致
+	origin := map[string]string{"status": "abc"}
+	generatedErr := new(model.User).FromRedisHash(origin)
+	reflectiveErr := reflectiveDecoder.Decode(origin, new(model.User))
+	if generatedErr == nil || reflectiveErr == nil || generatedErr.Error() != reflectiveErr.Error() {
+		t.Errorf("decode error not equal generated=%v reflective=%v", generatedErr, reflectiveErr)
+	}
+}
+
+type genBase struct {
+	Id int64 `redis:"id;required"`
+}
+
+type genModel struct {
+	*genBase
+	Name   string            `redis:"name;omitempty"`
+	Count  int               `redis:"count;default=1"`
+	Labels map[string]string `redis:"labels;omitempty"`
+	At     time.Time         `redis:"at;omitempty;time=unix"`
+	Scores map[int64]int64   `redis:"scores;codec=msgpack"`
+	Label  *wrapperspb.StringValue
+}
+
+type genNaming struct {
+	_      struct{} `redis:"naming=camel"`
+	UserID int64
+}
+
+type genUnsupported struct {
+	Ch chan int
+}
+
+func TestGenerate(t *testing.T) {
+	const pkgPath = "github.com/wanghuida/go-redis-ext/cmd/xhashgen/generator"
+	code, err := Generate(pkgPath, "generator", reflect.TypeOf(genModel{}))
+	if err != nil {
+		t.Errorf("generate err=%v", err)
+	}
+	for _, expected := range []string{`xhash.MarshalNested("scores", "msgpack", m.Scores)`, `xhash.MarshalNested("label", "proto", &(*m.Label))`} {
+		if !strings.Contains(string(code), expected) {
+			t.Errorf("generate should use field codec expected=%s", expected)
+		}
+	}
+	code, err = Generate(pkgPath, "generator", reflect.TypeOf(genNaming{}))
+	if err != nil || !strings.Contains(string(code), `origin["userId"]`) {
+		t.Errorf("generate should follow struct naming err=%v", err)
+	}
+	if _, err := Generate(pkgPath, "generator", reflect.TypeOf(genUnsupported{})); err == nil {
+		t.Errorf("unsupported field should fail")
+	}
+	if _, err := Generate(modelPath, "model", reflect.TypeOf(genModel{})); err == nil {
+		t.Errorf("type in other package should fail")
+	}
+}
@@ -0,0 +1,140 @@
+// xhashgen 为结构体生成 ToRedisHash / FromRedisHash 方法，xhash 转换时会自动使用，避免反射的开销
+//
+// 在模型所在的文件中添加:
+//
+//	//go:generate go run github.com/wanghuida/go-redis-ext/cmd/xhashgen -type User
+//
+// 执行 go generate 后生成 <file>_xhash.go，结构体修改后需要重新生成
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+const generatorPath = "github.com/wanghuida/go-redis-ext/cmd/xhashgen/generator"
+
+var (
+	typeNames = flag.String("type", "", "需要生成的结构体名称，多个以逗号分隔，默认为文件中的所有结构体")
+	output    = flag.String("output", "", "生成的文件名称，默认为 <file>_xhash.go")
+)
+
+func main() {
+	flag.Parse()
+	file := os.Getenv("GOFILE")
+	if flag.NArg() > 0 {
+		file = flag.Arg(0)
+	}
+	if file == "" {
+		fmt.Fprintln(os.Stderr, "usage: xhashgen [-type T1,T2] [-output file] [file.go]")
+		os.Exit(2)
+	}
+	if err := run(file); err != nil {
+		fmt.Fprintln(os.Stderr, "xhashgen:", err)
+		os.Exit(1)
+	}
+}
+
+func run(file string) error {
+	dir := filepath.Dir(file)
+	pkgName, structs, err := parseFile(file)
+	if err != nil {
+		return err
+	}
+	types := structs
+	if *typeNames != "" {
+		types = strings.Split(*typeNames, ",")
+	}
+	if len(types) == 0 {
+		return fmt.Errorf("no struct type in %s", file)
+	}
+
+	out := *output
+	if out == "" {
+		out = strings.TrimSuffix(file, ".go") + "_xhash.go"
+	} else if !filepath.IsAbs(out) {
+		out = filepath.Join(dir, out)
+	}
+	// 旧的生成文件可能与修改后的结构体不一致，先删除再编译
+	if err := os.Remove(out); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	pkgPath, err := command(dir, "go", "list", "-f", "{{.ImportPath}}", ".")
+	if err != nil {
+		return err
+	}
+	pkgPath = strings.TrimSpace(pkgPath)
+
+	code, err := generate(dir, pkgPath, pkgName, types)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(out, []byte(code), 0644)
+}
+
+// parseFile 文件的包名及其中的结构体名称
+func parseFile(file string) (string, []string, error) {
+	f, err := parser.ParseFile(token.NewFileSet(), file, nil, 0)
+	if err != nil {
+		return "", nil, err
+	}
+	var structs []string
+	for _, decl := range f.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || gen.Tok != token.TYPE {
+			continue
+		}
+		for _, spec := range gen.Specs {
+			typeSpec := spec.(*ast.TypeSpec)
+			if _, ok := typeSpec.Type.(*ast.StructType); ok && typeSpec.TypeParams == nil {
+				structs = append(structs, typeSpec.Name.Name)
+			}
+		}
+	}
+	return f.Name.Name, structs, nil
+}
+
+// generate 在包的目录中创建临时的 main 程序，通过反射取得结构体信息后生成代码
+func generate(dir, pkgPath, pkgName string, types []string) (string, error) {
+	tmpDir, err := os.MkdirTemp(dir, "xhashgen-")
+	if err != nil {
+		return "", err
+	}
+	defer os.RemoveAll(tmpDir)
+
+	var src bytes.Buffer
+	src.WriteString("package main\n\nimport (\n\t\"fmt\"\n\t\"os\"\n\t\"reflect\"\n\n")
+	fmt.Fprintf(&src, "\tgenerator %q\n\tpkg %q\n)\n\n", generatorPath, pkgPath)
+	src.WriteString("func main() {\n")
+	fmt.Fprintf(&src, "\tcode, err := generator.Generate(%q, %q", pkgPath, pkgName)
+	for _, name := range types {
+		fmt.Fprintf(&src, ",\n\t\treflect.TypeOf(pkg.%s{})", strings.TrimSpace(name))
+	}
+	src.WriteString(")\n\tif err != nil {\n\t\tfmt.Fprintln(os.Stderr, err)\n\t\tos.Exit(1)\n\t}\n\tos.Stdout.Write(code)\n}\n")
+	if err := os.WriteFile(filepath.Join(tmpDir, "main.go"), src.Bytes(), 0644); err != nil {
+		return "", err
+	}
+
+	return command(dir, "go", "run", "./"+filepath.Base(tmpDir))
+}
+
+func command(dir, name string, args ...string) (string, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command(name, args...)
+	cmd.Dir = dir
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("%s %s: %v\n%s", name, strings.Join(args, " "), err, stderr.String())
+	}
+	return stdout.String(), nil
+}
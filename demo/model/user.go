@@ -20,6 +20,8 @@ type UserInfo struct {
 	Nickname string
 }
 
+//go:generate go run github.com/wanghuida/go-redis-ext/cmd/xhashgen -type User
+
 // User 用户基本信息
 type User struct {
 	Id          int64
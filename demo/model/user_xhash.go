@@ -0,0 +1,212 @@
+// Code generated by xhashgen. DO NOT EDIT.
+
+package model
+
+import (
+	"github.com/wanghuida/go-redis-ext/xredis/xhash"
+	"strconv"
+	"time"
+)
+
+var (
+	xhashUserCreatedAtTime = xhash.NewTimeCodec("created_at", "", "")
+	xhashUserUpdatedAtTime = xhash.NewTimeCodec("updated_at", "", "")
+)
+
+// ToRedisHash 模型转 map，由 xhashgen 生成
+func (m *User) ToRedisHash() (map[string]interface{}, error) {
+	result := make(map[string]interface{}, 11)
+	result["id"] = int64(m.Id)
+	if m.RedPacketId == nil {
+		result["red_packet_id"] = nil
+	} else {
+		result["red_packet_id"] = int64((*m.RedPacketId))
+	}
+	result["name"] = m.Name
+	if v, e := xhash.JSONCodec.Marshal(m.Tags); e != nil {
+		return nil, e
+	} else {
+		result["tags"] = v
+	}
+	result["status"] = int64(m.Status)
+	result["is_new"] = bool(m.IsNew)
+	result["score"] = float64(m.Score)
+	if v, e := xhash.JSONCodec.Marshal(m.Friends); e != nil {
+		return nil, e
+	} else {
+		result["friends"] = v
+	}
+	if m.Info == nil {
+		result["user_info"] = nil
+	} else {
+		if v, e := xhash.JSONCodec.Marshal((*m.Info)); e != nil {
+			return nil, e
+		} else {
+			result["user_info"] = v
+		}
+	}
+	if v, e := xhashUserCreatedAtTime.Encode(m.CreatedAt); e != nil {
+		return nil, e
+	} else {
+		result["created_at"] = v
+	}
+	if m.UpdatedAt == nil {
+		result["updated_at"] = nil
+	} else {
+		if v, e := xhashUserUpdatedAtTime.Encode((*m.UpdatedAt)); e != nil {
+			return nil, e
+		} else {
+			result["updated_at"] = v
+		}
+	}
+	return result, nil
+}
+
+// FromRedisHash map 转模型，由 xhashgen 生成
+func (m *User) FromRedisHash(origin map[string]string) error {
+	if v, ok := origin["id"]; ok {
+		var err error
+		if x, e := xhash.ParseIntField(v, 64, "int64"); e != nil {
+			err = e
+		} else {
+			m.Id = int64(x)
+		}
+		if err != nil {
+			return &xhash.FieldError{Struct: "model.User", Field: "Id", Key: "id", Value: v, Err: err}
+		}
+	}
+	if v, ok := origin["red_packet_id"]; ok {
+		var err error
+		{
+			p0 := new(int64)
+			if x, e := xhash.ParseIntField(v, 64, "int64"); e != nil {
+				err = e
+			} else {
+				(*p0) = int64(x)
+			}
+			if err == nil {
+				m.RedPacketId = p0
+			}
+		}
+		if err != nil {
+			return &xhash.FieldError{Struct: "model.User", Field: "RedPacketId", Key: "red_packet_id", Value: v, Err: err}
+		}
+	}
+	if v, ok := origin["name"]; ok {
+		var err error
+		m.Name = string(v)
+		if err != nil {
+			return &xhash.FieldError{Struct: "model.User", Field: "Name", Key: "name", Value: v, Err: err}
+		}
+	}
+	if v, ok := origin["tags"]; ok {
+		var err error
+		{
+			var x []string
+			if e := xhash.JSONCodec.Unmarshal([]byte(v), &x); e != nil {
+				err = e
+			} else {
+				m.Tags = x
+			}
+		}
+		if err != nil {
+			return &xhash.FieldError{Struct: "model.User", Field: "Tags", Key: "tags", Value: v, Err: err}
+		}
+	}
+	if v, ok := origin["status"]; ok {
+		var err error
+		if x, e := xhash.ParseIntField(v, strconv.IntSize, "model.UserStatus"); e != nil {
+			err = e
+		} else {
+			m.Status = UserStatus(x)
+		}
+		if err != nil {
+			return &xhash.FieldError{Struct: "model.User", Field: "Status", Key: "status", Value: v, Err: err}
+		}
+	}
+	if v, ok := origin["is_new"]; ok {
+		var err error
+		if x, e := strconv.ParseBool(v); e != nil {
+			err = e
+		} else {
+			m.IsNew = bool(x)
+		}
+		if err != nil {
+			return &xhash.FieldError{Struct: "model.User", Field: "IsNew", Key: "is_new", Value: v, Err: err}
+		}
+	}
+	if v, ok := origin["score"]; ok {
+		var err error
+		if x, e := xhash.ParseFloatField(v, 64, "float64"); e != nil {
+			err = e
+		} else {
+			m.Score = float64(x)
+		}
+		if err != nil {
+			return &xhash.FieldError{Struct: "model.User", Field: "Score", Key: "score", Value: v, Err: err}
+		}
+	}
+	if v, ok := origin["friends"]; ok {
+		var err error
+		{
+			var x map[int64]UserInfo
+			if e := xhash.JSONCodec.Unmarshal([]byte(v), &x); e != nil {
+				err = e
+			} else {
+				m.Friends = x
+			}
+		}
+		if err != nil {
+			return &xhash.FieldError{Struct: "model.User", Field: "Friends", Key: "friends", Value: v, Err: err}
+		}
+	}
+	if v, ok := origin["user_info"]; ok {
+		var err error
+		{
+			p0 := new(UserInfo)
+			{
+				var x UserInfo
+				if e := xhash.JSONCodec.Unmarshal([]byte(v), &x); e != nil {
+					err = e
+				} else {
+					(*p0) = x
+				}
+			}
+			if err == nil {
+				m.Info = p0
+			}
+		}
+		if err != nil {
+			return &xhash.FieldError{Struct: "model.User", Field: "Info", Key: "user_info", Value: v, Err: err}
+		}
+	}
+	if v, ok := origin["created_at"]; ok {
+		var err error
+		if x, e := xhashUserCreatedAtTime.Decode(v); e != nil {
+			err = e
+		} else {
+			m.CreatedAt = x
+		}
+		if err != nil {
+			return &xhash.FieldError{Struct: "model.User", Field: "CreatedAt", Key: "created_at", Value: v, Err: err}
+		}
+	}
+	if v, ok := origin["updated_at"]; ok {
+		var err error
+		{
+			p0 := new(time.Time)
+			if x, e := xhashUserUpdatedAtTime.Decode(v); e != nil {
+				err = e
+			} else {
+				(*p0) = x
+			}
+			if err == nil {
+				m.UpdatedAt = p0
+			}
+		}
+		if err != nil {
+			return &xhash.FieldError{Struct: "model.User", Field: "UpdatedAt", Key: "updated_at", Value: v, Err: err}
+		}
+	}
+	return nil
+}
@@ -1,10 +1,12 @@
 package main
 
 import (
-	"github.com/go-redis/redis"
+	"context"
 	"github.com/k0kubun/pp"
+	"github.com/redis/go-redis/v9"
 	"github.com/wanghuida/go-redis-ext/demo/model"
 	"github.com/wanghuida/go-redis-ext/xredis/xhash"
+	"github.com/wanghuida/go-redis-ext/xredis/xstore"
 )
 
 func main() {
@@ -15,13 +17,23 @@ func main() {
 		Password: "123456",
 		DB:       0,
 	})
-	result := redisClient.HGetAll("user1").Val()
+	result, err := redisClient.HGetAll(context.Background(), "user1").Result()
+	if err != nil {
+		panic(err)
+	}
 
 	user := new(model.User)
-	err := xhash.Map2model(result, user)
+	err = xhash.Map2model(result, user)
+	if err != nil {
+		panic(err)
+	}
+
+	// 也可以直接从命令的结果转模型，key 不存在时 found 为 false
+	found, err := xstore.ScanHash(redisClient.HGetAll(context.Background(), "user1"), user)
 	if err != nil {
 		panic(err)
 	}
+	pp.Println(found)
 
 	pp.Println(user)
 
@@ -1,8 +1,9 @@
 package main
 
 import (
-	"github.com/go-redis/redis"
+	"context"
 	"github.com/k0kubun/pp"
+	"github.com/redis/go-redis/v9"
 	"github.com/wanghuida/go-redis-ext/demo/model"
 	"github.com/wanghuida/go-redis-ext/xredis/xhash"
 	"time"
@@ -46,7 +47,7 @@ func main() {
 		Password: "123456",
 		DB:       0,
 	})
-	err = redisClient.HMSet("user1", result).Err()
+	err = redisClient.HSet(context.Background(), "user1", result).Err()
 	if err != nil {
 		panic(err)
 	}
@@ -0,0 +1,233 @@
+package xhash
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/pkg/errors"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// ----------------------------------------
+// 引入字段缓存之前的实现，每次转换都用 FieldByName 查找字段并重新分析 tag，
+// 只保留 benchModel 用到的类型，作为性能对比的基准
+// ----------------------------------------
+
+func baselineParseTag(field reflect.StructField) *FieldTag {
+	fieldTag := &FieldTag{Name: Hump2underline(field.Name)}
+	tagGroup := strings.Split(field.Tag.Get(XHashTag), XHashTagSep)
+	if len(tagGroup) == 1 && tagGroup[0] == "" {
+		return fieldTag
+	}
+	if tagGroup[0] == "-" {
+		fieldTag.IsIgnore = true
+		return fieldTag
+	}
+	fieldTag.Name = tagGroup[0]
+	return fieldTag
+}
+
+func baselineMap2model(origin map[string]string, target interface{}) error {
+	targetValue := reflect.ValueOf(target).Elem()
+	for i := 0; i < targetValue.NumField(); i++ {
+		field := targetValue.Type().Field(i)
+		tag := baselineParseTag(field)
+		if tag.IsIgnore {
+			continue
+		}
+		originVal, has := origin[tag.Name]
+		if !has {
+			continue
+		}
+		if err := baselineSetValue(targetValue, field, originVal); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func baselineSetValue(targetValue reflect.Value, field reflect.StructField, originVal string) error {
+	fieldValue := targetValue.FieldByName(field.Name)
+	if field.Type.Kind() == reflect.Ptr {
+		return baselineSetPtrValue(targetValue, field, originVal)
+	}
+
+	switch field.Type.Kind() {
+	case reflect.Int64, reflect.Int32, reflect.Int16, reflect.Int8, reflect.Int:
+		intVal, err := strconv.ParseInt(originVal, 10, 64)
+		if err != nil {
+			return err
+		}
+		fieldValue.SetInt(intVal)
+	case reflect.String:
+		fieldValue.SetString(originVal)
+	case reflect.Bool:
+		boolVal, err := strconv.ParseBool(originVal)
+		if err != nil {
+			return err
+		}
+		fieldValue.SetBool(boolVal)
+	case reflect.Float64, reflect.Float32:
+		floatVal, err := strconv.ParseFloat(originVal, 64)
+		if err != nil {
+			return err
+		}
+		fieldValue.SetFloat(floatVal)
+	case reflect.Slice:
+		slice := reflect.New(reflect.SliceOf(fieldValue.Type().Elem()))
+		if err := json.Unmarshal(bytes.NewBufferString(originVal).Bytes(), slice.Interface()); err != nil {
+			return err
+		}
+		fieldValue.Set(reflect.ValueOf(slice.Interface()).Elem())
+	case reflect.Struct:
+		if field.Type.String() == "time.Time" {
+			timeTime, err := time.ParseInLocation("2006-01-02 15:04:05", originVal, time.Local)
+			if err != nil {
+				return err
+			}
+			fieldValue.Set(reflect.ValueOf(timeTime))
+			return nil
+		}
+		obj := reflect.New(fieldValue.Type())
+		if err := json.Unmarshal(bytes.NewBufferString(originVal).Bytes(), obj.Interface()); err != nil {
+			return err
+		}
+		fieldValue.Set(obj.Elem())
+	default:
+		errMsg := fmt.Sprintf("unsupported type name=%s type=%s", field.Name, field.Type)
+		return errors.New(errMsg)
+	}
+	return nil
+}
+
+func baselineSetPtrValue(targetValue reflect.Value, field reflect.StructField, originVal string) error {
+	fieldValue := targetValue.FieldByName(field.Name)
+	switch fieldValue.Type().Elem().Kind() {
+	case reflect.Int64:
+		intVal, err := strconv.ParseInt(originVal, 10, 64)
+		if err != nil {
+			return err
+		}
+		fieldValue.Set(reflect.ValueOf(&intVal))
+	default:
+		if fieldValue.Type().Elem().String() == "time.Time" {
+			timeTime, err := time.ParseInLocation("2006-01-02 15:04:05", originVal, time.Local)
+			if err != nil {
+				return err
+			}
+			fieldValue.Set(reflect.ValueOf(&timeTime))
+			return nil
+		}
+		obj := reflect.New(fieldValue.Type().Elem())
+		if err := json.Unmarshal(bytes.NewBufferString(originVal).Bytes(), obj.Interface()); err != nil {
+			return err
+		}
+		fieldValue.Set(obj)
+	}
+	return nil
+}
+
+func baselineModel2map(origin interface{}) (map[string]interface{}, error) {
+	originValue := reflect.ValueOf(origin).Elem()
+	result := make(map[string]interface{})
+	for i := 0; i < originValue.NumField(); i++ {
+		field := originValue.Type().Field(i)
+		tag := baselineParseTag(field)
+		if tag.IsIgnore {
+			continue
+		}
+		value, err := baselineGetValue(originValue, field)
+		if err != nil {
+			return nil, err
+		}
+		result[tag.Name] = value
+	}
+	return result, nil
+}
+
+func baselineGetValue(originValue reflect.Value, field reflect.StructField) (interface{}, error) {
+	fieldValue := originValue.FieldByName(field.Name)
+	if field.Type.Kind() == reflect.Ptr {
+		if fieldValue.IsNil() {
+			return nil, nil
+		}
+		if fieldValue.Type().Elem().String() == "time.Time" {
+			return fieldValue.Elem().Interface().(time.Time).Local().Format("2006-01-02 15:04:05"), nil
+		}
+		if fieldValue.Type().Elem().Kind() == reflect.Struct {
+			return json.Marshal(fieldValue.Elem().Interface())
+		}
+		return fieldValue.Elem().Interface(), nil
+	}
+
+	switch field.Type.Kind() {
+	case reflect.Int64, reflect.Int32, reflect.Int16, reflect.Int8, reflect.Int:
+		return fieldValue.Int(), nil
+	case reflect.String:
+		return fieldValue.Interface(), nil
+	case reflect.Bool:
+		return fieldValue.Bool(), nil
+	case reflect.Float64, reflect.Float32:
+		return fieldValue.Float(), nil
+	case reflect.Slice:
+		return json.Marshal(fieldValue.Interface())
+	case reflect.Struct:
+		if field.Type.String() == "time.Time" {
+			return fieldValue.Interface().(time.Time).Local().Format("2006-01-02 15:04:05"), nil
+		}
+		return json.Marshal(fieldValue.Interface())
+	default:
+		errMsg := fmt.Sprintf("unsupported type name=%s type=%s", field.Name, field.Type)
+		return nil, errors.New(errMsg)
+	}
+}
+
+func BenchmarkMap2modelBaseline(b *testing.B) {
+	data := newBenchMap()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if err := baselineMap2model(data, new(benchModel)); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkModel2mapBaseline(b *testing.B) {
+	model := newBenchModel()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := baselineModel2map(model); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// TestBaselineEquivalent 基准实现与当前实现的结果一致，对比才有意义
+func TestBaselineEquivalent(t *testing.T) {
+	expected, err := Model2map(newBenchModel())
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := baselineModel2map(newBenchModel())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result) != len(expected) {
+		t.Errorf("baseline model2map err result=%v expected=%v", result, expected)
+	}
+
+	expectedModel, baselineModel := new(benchModel), new(benchModel)
+	if err := Map2model(newBenchMap(), expectedModel); err != nil {
+		t.Fatal(err)
+	}
+	if err := baselineMap2model(newBenchMap(), baselineModel); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(expectedModel, baselineModel) {
+		t.Errorf("baseline map2model err result=%+v expected=%+v", baselineModel, expectedModel)
+	}
+}
@@ -0,0 +1,106 @@
+package xhash
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+type benchInfo struct {
+	Id       int64
+	Nickname string
+}
+
+type benchModel struct {
+	Id          int64
+	RedPacketId *int64
+	Name        string
+	Tags        []string
+	Status      int
+	IsNew       bool
+	Score       float64
+	Info        *benchInfo `redis:"user_info"`
+	CreatedAt   time.Time
+	UpdatedAt   *time.Time
+	Ignore      string `redis:"-"`
+}
+
+func newBenchModel() *benchModel {
+	redPacketId := int64(10001)
+	now := time.Now()
+	return &benchModel{
+		Id:          1,
+		RedPacketId: &redPacketId,
+		Name:        "william",
+		Tags:        []string{"man", "pupil"},
+		Status:      1,
+		IsNew:       true,
+		Score:       3.1415,
+		Info:        &benchInfo{Id: 2, Nickname: "Bob"},
+		CreatedAt:   now,
+		UpdatedAt:   &now,
+	}
+}
+
+func newBenchMap() map[string]string {
+	return map[string]string{
+		"id":            "1",
+		"red_packet_id": "10001",
+		"name":          "william",
+		"tags":          `["man","pupil"]`,
+		"status":        "1",
+		"is_new":        "1",
+		"score":         "3.1415",
+		"user_info":     `{"Id":2,"Nickname":"Bob"}`,
+		"created_at":    "2019-05-22 14:25:41",
+		"updated_at":    "2019-05-22 14:25:41",
+	}
+}
+
+// 每次都重新分析结构体，衡量首次转换的开销；与缓存之前的实现对比见 bench_baseline_test.go
+func dropStructCache(v interface{}) {
+	DefaultRegistry.engine.cache.Delete(reflect.TypeOf(v).Elem())
+}
+
+func BenchmarkMap2model(b *testing.B) {
+	data := newBenchMap()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if err := Map2model(data, new(benchModel)); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkMap2modelNoCache(b *testing.B) {
+	data := newBenchMap()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		result := new(benchModel)
+		dropStructCache(result)
+		if err := Map2model(data, result); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkModel2map(b *testing.B) {
+	model := newBenchModel()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := Model2map(model); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkModel2mapNoCache(b *testing.B) {
+	model := newBenchModel()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		dropStructCache(model)
+		if _, err := Model2map(model); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,327 @@
+package xhash
+
+import (
+	"fmt"
+	"github.com/pkg/errors"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// timeType time.Time 的类型，需要特殊处理
+var timeType = reflect.TypeOf(time.Time{})
+
+// encodeFunc 字段值转成可存储的值
+type encodeFunc func(fieldValue reflect.Value) (interface{}, error)
+
+// decodeFunc 将 hash 中的字符串填充到字段
+type decodeFunc func(fieldValue reflect.Value, originVal string) error
+
+// fieldInfo 预先分析好的字段信息
+type fieldInfo struct {
+	name   string // 字段在 hash 中的名称
+	index  []int  // 字段的索引路径
+	path   string // Go 字段路径，内嵌结构体以 . 分隔
+	tagged bool   // 是否在 tag 中指定了名称
+	field  reflect.StructField
+	tag    *FieldTag
+	encode encodeFunc
+	decode decodeFunc
+}
+
+// structInfo 预先分析好的结构体信息
+type structInfo struct {
+	fields    []*fieldInfo
+	names     map[string]bool          // hash 中对应的所有名称
+	model     *ModelTag                // 模型级别的选项
+	key       []keySegment             // 分析后的 key 模板
+	keyErr    error                    // key 模板有误时的错误，生成 key 时返回
+	verField  *fieldInfo               // 带 version 选项的版本字段
+	verErr    error                    // 版本字段有误时的错误，使用版本时返回
+	ttl       time.Duration            // 整个 hash 的过期时间
+	fieldTTL  map[string]time.Duration // hash 字段的过期时间，key 为 hash 中的名称
+	ttlErr    error                    // 过期时间有误时的错误，获取过期时间时返回
+	namingErr error                    // 命名规则有误时的错误，转换时返回
+	generated bool                     // 是否可以使用生成的方法
+	version   uint64                   // 分析时转换器注册表的版本
+}
+
+// engine 负责结构体的分析与转换，分析结果与配置有关，所以各自缓存
+type engine struct {
+	opts      Options
+	registry  *Registry
+	generated bool     // 配置是否允许使用生成的方法
+	cache     sync.Map // 按类型缓存结构体信息，map[reflect.Type]*structInfo
+}
+
+func newEngine(opts Options) *engine {
+	generated := opts.canUseGenerated()
+	opts = opts.withDefaults()
+	return &engine{opts: opts, registry: opts.Registry, generated: generated}
+}
+
+// cachedStructInfo 获取结构体信息，每种类型只分析一次，注册了新的转换器后重新分析
+func (e *engine) cachedStructInfo(t reflect.Type) *structInfo {
+	version := e.registry.currentVersion()
+	if info, ok := e.cache.Load(t); ok && info.(*structInfo).version == version {
+		return info.(*structInfo)
+	}
+	info := e.newStructInfo(t)
+	info.version = version
+	e.cache.Store(t, info)
+	return info
+}
+
+// newStructInfo 分析结构体的每一个字段
+// 内嵌结构体及带 inline 选项的结构体字段会被展开，提升上来的同名字段取舍规则同 encoding/json：
+// 层级浅的优先，同一层级有 tag 命名的优先，仍然无法区分则都忽略；同一层级内嵌多次的类型，其中的字段都忽略；
+// 配置了 SharedNames 时最外层的同名字段都保留
+// 结构体通过 naming 选项声明的命名规则用于自身的字段，未声明时沿用外层结构体的规则
+func (e *engine) newStructInfo(t reflect.Type) *structInfo {
+	// 待展开的结构体
+	type embedded struct {
+		typ    reflect.Type
+		index  []int
+		path   string
+		naming NamingStrategy
+	}
+
+	var fields []*fieldInfo
+	var current []embedded
+	var namingErr error
+	next := []embedded{{typ: t, naming: e.opts.Naming}}
+	visited := make(map[reflect.Type]bool)
+
+	// 每种类型在当前及下一层出现的次数
+	var count map[reflect.Type]int
+	nextCount := map[reflect.Type]int{}
+
+	// 一层一层的展开
+	for len(next) > 0 {
+		current, next = next, nil
+		count, nextCount = nextCount, map[reflect.Type]int{}
+		for _, item := range current {
+			// 已经展开过的类型，更深层的字段不会胜出，避免死循环
+			if visited[item.typ] {
+				continue
+			}
+			visited[item.typ] = true
+
+			naming, err := structNaming(item.typ, e.opts.TagKey, item.naming)
+			if err != nil && namingErr == nil {
+				namingErr = err
+			}
+			for i := 0; i < item.typ.NumField(); i++ {
+				field := item.typ.Field(i)
+				fieldType := field.Type
+				if fieldType.Kind() == reflect.Ptr {
+					fieldType = fieldType.Elem()
+				}
+				if field.PkgPath != "" {
+					// 未导出的内嵌结构体，导出的字段仍然可以提升，但无法为指针分配空间
+					if !field.Anonymous || fieldType.Kind() != reflect.Struct || field.Type.Kind() == reflect.Ptr {
+						continue
+					}
+				}
+				tag := parseTag(field, e.opts.TagKey, naming)
+				// 忽略直接跳过
+				if tag.IsIgnore {
+					continue
+				}
+
+				index := make([]int, len(item.index)+1)
+				copy(index, item.index)
+				index[len(item.index)] = i
+
+				path := field.Name
+				if item.path != "" {
+					path = item.path + "." + field.Name
+				}
+
+				// 需要展开的结构体，放到下一层处理
+				tagged := hasTagName(field, e.opts.TagKey)
+				if isInlineStruct(field, fieldType, tag, tagged) {
+					nextCount[fieldType]++
+					if nextCount[fieldType] == 1 {
+						next = append(next, embedded{typ: fieldType, index: index, path: path, naming: naming})
+					}
+					continue
+				}
+				// 未导出的内嵌结构体不展开时也无法读写
+				if field.PkgPath != "" {
+					continue
+				}
+
+				f := &fieldInfo{
+					name:   tag.Name,
+					index:  index,
+					path:   path,
+					tagged: tagged,
+					field:  field,
+					tag:    tag,
+					encode: e.encoderFor(field, tag),
+					decode: e.decoderFor(field, tag),
+				}
+				fields = append(fields, f)
+				// 类型在这一层内嵌了多次，再加一份让同名字段冲突而被忽略
+				if count[item.typ] > 1 {
+					fields = append(fields, f)
+				}
+			}
+		}
+	}
+
+	info := &structInfo{fields: dominantFields(fields, e.opts.SharedNames), names: make(map[string]bool), namingErr: namingErr}
+	for _, field := range info.fields {
+		info.names[field.name] = true
+	}
+
+	info.model = parseModelTag(t, e.opts.TagKey)
+	if info.model.Key != "" {
+		info.key, info.keyErr = parseKeyPattern(info.model.Key, info)
+	}
+	info.verField, info.verErr = versionField(info)
+	info.ttl, info.fieldTTL, info.ttlErr = parseTTLs(info)
+	info.generated = namingErr == nil && e.generatedFor(t, info.fields)
+	return info
+}
+
+// structNaming 结构体声明的命名规则，未声明时返回 inherited
+func structNaming(t reflect.Type, tagKey string, inherited NamingStrategy) (NamingStrategy, error) {
+	name := parseModelTag(t, tagKey).Naming
+	if name == "" {
+		return inherited, nil
+	}
+	naming, ok := LookupNaming(name)
+	if !ok {
+		errMsg := fmt.Sprintf("unknown naming strategy struct=%s naming=%s", t, name)
+		return inherited, errors.New(errMsg)
+	}
+	return naming, nil
+}
+
+// selectFields 按 Go 字段名选取字段，内嵌结构体中的字段可以用提升后的名称或完整路径
+// names 为空时返回所有字段
+func (info *structInfo) selectFields(names []string) ([]*fieldInfo, error) {
+	if info.namingErr != nil {
+		return nil, info.namingErr
+	}
+	if len(names) == 0 {
+		return info.fields, nil
+	}
+	fields := make([]*fieldInfo, 0, len(names))
+	for _, name := range names {
+		field := info.lookup(name)
+		if field == nil {
+			errMsg := fmt.Sprintf("unknown struct field name=%s", name)
+			return nil, errors.New(errMsg)
+		}
+		fields = append(fields, field)
+	}
+	return fields, nil
+}
+
+// lookup 按完整路径查找字段，找不到时再按提升后的 Go 字段名查找
+func (info *structInfo) lookup(name string) *fieldInfo {
+	for _, field := range info.fields {
+		if field.path == name {
+			return field
+		}
+	}
+	for _, field := range info.fields {
+		if field.field.Name == name {
+			return field
+		}
+	}
+	return nil
+}
+
+// isInlineStruct 带 inline 选项的结构体需要展开，
+// 内嵌且未命名的结构体，没有自定义存储格式时也需要展开
+func isInlineStruct(field reflect.StructField, fieldType reflect.Type, tag *FieldTag, tagged bool) bool {
+	if fieldType.Kind() != reflect.Struct || fieldType == timeType {
+		return false
+	}
+	if tag.Inline {
+		return true
+	}
+	return field.Anonymous && !tagged && !hasMarshaler(fieldType)
+}
+
+// hasTagName tag 中是否指定了存储名称
+func hasTagName(field reflect.StructField, tagKey string) bool {
+	tagStr := field.Tag.Get(tagKey)
+	return strings.Split(tagStr, XHashTagSep)[0] != ""
+}
+
+// dominantFields 处理同名字段，并按字段在结构体中的顺序排列；shared 为 true 时最外层的同名字段都保留
+func dominantFields(fields []*fieldInfo, shared bool) []*fieldInfo {
+	// 同名的按层级，是否有 tag 命名排序
+	sort.SliceStable(fields, func(i, j int) bool {
+		if fields[i].name != fields[j].name {
+			return fields[i].name < fields[j].name
+		}
+		if len(fields[i].index) != len(fields[j].index) {
+			return len(fields[i].index) < len(fields[j].index)
+		}
+		return fields[i].tagged && !fields[j].tagged
+	})
+
+	result := fields[:0]
+	for i := 0; i < len(fields); {
+		j := i + 1
+		for j < len(fields) && fields[j].name == fields[i].name {
+			j++
+		}
+		if shared && len(fields[i].index) == 1 {
+			for k := i; k < j && len(fields[k].index) == 1; k++ {
+				result = append(result, fields[k])
+			}
+		} else if dominant, ok := dominantField(fields[i:j]); ok {
+			result = append(result, dominant)
+		}
+		i = j
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return indexLess(result[i].index, result[j].index)
+	})
+	return result
+}
+
+// dominantField 同名字段中胜出的字段，已经按层级与 tag 排好序
+func dominantField(fields []*fieldInfo) (*fieldInfo, bool) {
+	if len(fields) > 1 && len(fields[0].index) == len(fields[1].index) && fields[0].tagged == fields[1].tagged {
+		return nil, false
+	}
+	return fields[0], true
+}
+
+func indexLess(a, b []int) bool {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if a[i] != b[i] {
+			return a[i] < b[i]
+		}
+	}
+	return len(a) < len(b)
+}
+
+// fieldByIndex 按索引路径取字段，路径上的内嵌指针为 nil 时，
+// alloc 为 true 则分配空间，否则返回 false
+func fieldByIndex(v reflect.Value, index []int, alloc bool) (reflect.Value, bool) {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				if !alloc {
+					return reflect.Value{}, false
+				}
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v, true
+}
@@ -0,0 +1,200 @@
+package xhash
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestCachedStructInfo(t *testing.T) {
+	type model struct {
+		Id      int64
+		Name    string `redis:"nickname"`
+		Ignore  string `redis:"-"`
+		private string
+	}
+
+	typ := reflect.TypeOf(model{})
+	info := DefaultRegistry.engine.cachedStructInfo(typ)
+	if len(info.fields) != 2 {
+		t.Fatalf("field count err count=%d", len(info.fields))
+	}
+	if info.fields[1].name != "nickname" {
+		t.Errorf("field name err name=%s", info.fields[1].name)
+	}
+
+	// 并发获取，返回的应该是同一份
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if DefaultRegistry.engine.cachedStructInfo(typ) != info {
+				t.Error("struct info not cached")
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+type EmbeddedBase struct {
+	Id        int64
+	CreatedAt time.Time
+	Name      string
+}
+
+type EmbeddedExtra struct {
+	Name  string
+	Score int
+}
+
+type EmbeddedAddress struct {
+	City string
+}
+
+func TestEmbeddedStruct(t *testing.T) {
+	type model struct {
+		*EmbeddedBase
+		EmbeddedExtra
+		Name    string          `redis:"nickname"`
+		Address EmbeddedAddress `redis:";inline"`
+		Home    EmbeddedAddress
+	}
+
+	createdAt := time.Date(2019, 5, 23, 10, 20, 30, 0, time.Local)
+	origin := &model{
+		EmbeddedBase:  &EmbeddedBase{Id: 1, CreatedAt: createdAt, Name: "base"},
+		EmbeddedExtra: EmbeddedExtra{Name: "extra", Score: 10},
+		Name:          "william",
+		Address:       EmbeddedAddress{City: "beijing"},
+	}
+	data, err := Model2map(origin)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 内嵌的同名字段在同一层级冲突，都被忽略；外层的 tag 命名不影响
+	expected := []string{"id", "created_at", "score", "nickname", "city", "home"}
+	if len(data) != len(expected) {
+		t.Fatalf("embedded field count err data=%v", data)
+	}
+	for _, name := range expected {
+		if _, ok := data[name]; !ok {
+			t.Errorf("embedded field missing name=%s", name)
+		}
+	}
+
+	// 转回模型，内嵌的指针需要分配空间
+	hash := map[string]string{"id": "1", "score": "10", "nickname": "william", "city": "beijing"}
+	result := new(model)
+	if err := Map2model(hash, result); err != nil {
+		t.Fatal(err)
+	}
+	if result.EmbeddedBase == nil || result.Id != 1 {
+		t.Errorf("embedded ptr value err result=%+v", result)
+	}
+	if result.Score != 10 || result.Name != "william" || result.Address.City != "beijing" {
+		t.Errorf("embedded value err result=%+v", result)
+	}
+
+	// 内嵌指针为 nil 时，其中的字段不存储，也不会因为没有数据而分配
+	data, err = Model2map(&model{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := data["id"]; ok {
+		t.Errorf("nil embedded ptr should be skipped data=%v", data)
+	}
+	result = new(model)
+	if err := Map2model(map[string]string{"score": "1"}, result); err != nil {
+		t.Fatal(err)
+	}
+	if result.EmbeddedBase != nil {
+		t.Errorf("embedded ptr should not be allocated")
+	}
+}
+
+func TestEmbeddedShadow(t *testing.T) {
+	type model struct {
+		EmbeddedBase
+		Id   string
+		Name string `redis:"name"`
+	}
+	info := DefaultRegistry.engine.cachedStructInfo(reflect.TypeOf(model{}))
+	names := make(map[string][]int)
+	for _, field := range info.fields {
+		names[field.name] = field.index
+	}
+	// 外层的字段覆盖内嵌结构体中的字段
+	if index := names["id"]; len(index) != 1 || index[0] != 1 {
+		t.Errorf("shadow field err index=%v", index)
+	}
+	if index := names["name"]; len(index) != 1 || index[0] != 2 {
+		t.Errorf("shadow field err index=%v", index)
+	}
+	if index := names["created_at"]; len(index) != 2 {
+		t.Errorf("promoted field err index=%v", index)
+	}
+}
+
+type ShadowA struct {
+	EmbeddedBase
+	Score int
+}
+
+type ShadowB struct {
+	EmbeddedBase
+}
+
+func TestEmbeddedShadowJSON(t *testing.T) {
+	// 最外层有 tag 命名的字段优先
+	type tagged struct {
+		Nick string `redis:"name"`
+		Name string
+	}
+	data, err := Model2map(&tagged{Nick: "tagged", Name: "untagged"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(data) != 1 || data["name"] != "tagged" {
+		t.Errorf("tagged field should win data=%v", data)
+	}
+
+	// 最外层无法区分的同名字段都忽略
+	type conflict struct {
+		Nick string `redis:"name"`
+		Name string `redis:"name"`
+		Id   int64
+	}
+	data, err = Model2map(&conflict{Nick: "a", Name: "b", Id: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := data["id"]; len(data) != 1 || !ok {
+		t.Errorf("conflict fields should be dropped data=%v", data)
+	}
+
+	// 同一层级内嵌多次的类型，其中的字段都忽略
+	type twice struct {
+		ShadowA
+		ShadowB
+	}
+	data, err = Model2map(&twice{ShadowA: ShadowA{EmbeddedBase: EmbeddedBase{Id: 1}, Score: 2}, ShadowB: ShadowB{EmbeddedBase{Id: 3}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := data["score"]; len(data) != 1 || !ok {
+		t.Errorf("duplicate embedded fields should be dropped data=%v", data)
+	}
+
+	// SharedNames 保留最外层的同名字段
+	decoder := NewDecoder(Options{SharedNames: true})
+	result := new(tagged)
+	if err := decoder.Decode(map[string]string{"name": "william"}, result); err != nil {
+		t.Fatal(err)
+	}
+	if result.Nick != "william" || result.Name != "william" {
+		t.Errorf("shared names decode err result=%+v", result)
+	}
+}
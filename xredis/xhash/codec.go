@@ -0,0 +1,152 @@
+package xhash
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"fmt"
+	"github.com/pkg/errors"
+	"github.com/vmihailenco/msgpack/v5"
+	"google.golang.org/protobuf/proto"
+	"reflect"
+	"sync"
+)
+
+// Codec 切片、map、结构体等嵌套值的编解码方式
+type Codec interface {
+	Marshal(v interface{}) ([]byte, error)
+	Unmarshal(data []byte, v interface{}) error
+}
+
+var (
+	// JSONCodec 使用 encoding/json 编解码，默认的方式
+	JSONCodec Codec = jsonCodec{}
+
+	// MsgPackCodec 使用 MessagePack 编解码，比 json 小，保留 int64 的精度及非字符串的 map key
+	MsgPackCodec Codec = msgpackCodec{}
+
+	// GobCodec 使用 encoding/gob 编解码，只适合 Go 服务之间共享的数据
+	GobCodec Codec = gobCodec{}
+
+	// ProtoCodec 使用 protobuf 编解码，值需要是 proto.Message，实现了 proto.Message 的字段默认使用
+	ProtoCodec Codec = protoCodec{}
+)
+
+var (
+	codecMu sync.RWMutex
+	codecs  = map[string]Codec{
+		"json":    JSONCodec,
+		"msgpack": MsgPackCodec,
+		"gob":     GobCodec,
+		"proto":   ProtoCodec,
+	}
+)
+
+// RegisterCodec 注册自定义的编解码，之后可以在字段的 codec 选项中使用，应在初始化时注册
+func RegisterCodec(name string, codec Codec) {
+	codecMu.Lock()
+	defer codecMu.Unlock()
+	codecs[name] = codec
+}
+
+// LookupCodec 按名称查找编解码，内置 json、msgpack、gob、proto
+func LookupCodec(name string) (Codec, bool) {
+	codecMu.RLock()
+	defer codecMu.RUnlock()
+	codec, ok := codecs[name]
+	return codec, ok
+}
+
+type jsonCodec struct{}
+
+func (jsonCodec) Marshal(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (jsonCodec) Unmarshal(data []byte, v interface{}) error {
+	return json.Unmarshal(data, v)
+}
+
+type msgpackCodec struct{}
+
+func (msgpackCodec) Marshal(v interface{}) ([]byte, error) {
+	return msgpack.Marshal(v)
+}
+
+func (msgpackCodec) Unmarshal(data []byte, v interface{}) error {
+	return msgpack.Unmarshal(data, v)
+}
+
+type gobCodec struct{}
+
+func (gobCodec) Marshal(v interface{}) ([]byte, error) {
+	var buffer bytes.Buffer
+	if err := gob.NewEncoder(&buffer).Encode(v); err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
+
+func (gobCodec) Unmarshal(data []byte, v interface{}) error {
+	return gob.NewDecoder(bytes.NewReader(data)).Decode(v)
+}
+
+type protoCodec struct{}
+
+func (protoCodec) Marshal(v interface{}) ([]byte, error) {
+	message, ok := v.(proto.Message)
+	if !ok {
+		errMsg := fmt.Sprintf("proto codec expected proto.Message, got %T", v)
+		return nil, errors.New(errMsg)
+	}
+	return proto.Marshal(message)
+}
+
+func (protoCodec) Unmarshal(data []byte, v interface{}) error {
+	message, ok := v.(proto.Message)
+	if !ok {
+		errMsg := fmt.Sprintf("proto codec expected proto.Message, got %T", v)
+		return errors.New(errMsg)
+	}
+	return proto.Unmarshal(data, message)
+}
+
+var protoMessageType = reflect.TypeOf((*proto.Message)(nil)).Elem()
+
+// isProtoMessage 结构体的指针是否实现了 proto.Message
+func isProtoMessage(t reflect.Type) bool {
+	return t.Kind() == reflect.Struct && reflect.PtrTo(t).Implements(protoMessageType)
+}
+
+// nestedCodecName 字段嵌套值使用的编解码名称，为空时使用配置
+// tag 中的 codec 选项优先，其次 protobuf 的消息使用 proto
+func nestedCodecName(t reflect.Type, tag *FieldTag) string {
+	if tag.Codec != "" {
+		return tag.Codec
+	}
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if isProtoMessage(t) {
+		return "proto"
+	}
+	return ""
+}
+
+// nestedCodec 字段嵌套值使用的编解码，codec 选项的名称未注册时返回错误
+func (e *engine) nestedCodec(t reflect.Type, tag *FieldTag) (Codec, error) {
+	return lookupNestedCodec(tag.Name, nestedCodecName(t, tag), e.opts.Codec)
+}
+
+// lookupNestedCodec 按名称查找编解码，名称为空时返回 fallback
+func lookupNestedCodec(fieldName, codecName string, fallback Codec) (Codec, error) {
+	if codecName == "" {
+		return fallback, nil
+	}
+	codec, ok := LookupCodec(codecName)
+	if !ok {
+		errMsg := fmt.Sprintf("unknown codec name=%s codec=%s", fieldName, codecName)
+		return nil, errors.New(errMsg)
+	}
+	return codec, nil
+}
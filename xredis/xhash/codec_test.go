@@ -0,0 +1,100 @@
+package xhash
+
+import (
+	"bytes"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type codecModel struct {
+	Scores  map[int64]int64 `redis:"scores;codec=msgpack"`
+	Ids     []int64         `redis:"ids;codec=gob"`
+	Tags    []string        `redis:"tags"`
+	Name    *wrapperspb.StringValue
+	Unknown []string `redis:"unknown;codec=xml;omitempty"`
+}
+
+func TestCodec(t *testing.T) {
+	model := &codecModel{
+		Scores: map[int64]int64{1: 1<<62 + 1},
+		Ids:    []int64{1<<62 + 1},
+		Tags:   []string{"a"},
+		Name:   wrapperspb.String("hello"),
+	}
+	result, err := Model2map(model)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(result["tags"].([]byte), []byte(`["a"]`)) {
+		t.Errorf("default codec should be json tags=%s", result["tags"])
+	}
+	protoBytes, _ := proto.Marshal(model.Name)
+	if !bytes.Equal(result["name"].([]byte), protoBytes) {
+		t.Errorf("proto message should use proto codec name=%v", result["name"])
+	}
+
+	origin := make(map[string]string, len(result))
+	for key, value := range result {
+		origin[key] = formatValue(value)
+	}
+	decoded := &codecModel{}
+	if err := Map2model(origin, decoded); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(decoded.Scores, model.Scores) || !reflect.DeepEqual(decoded.Ids, model.Ids) ||
+		!reflect.DeepEqual(decoded.Tags, model.Tags) || !proto.Equal(decoded.Name, model.Name) {
+		t.Errorf("codec decode err decoded=%+v", decoded)
+	}
+
+	// 未注册的编解码在使用时报错
+	model.Unknown = []string{"a"}
+	if _, err := Model2map(model); err == nil || !strings.Contains(err.Error(), "unknown codec") {
+		t.Errorf("unknown codec should fail err=%v", err)
+	}
+	if err := Map2model(map[string]string{"unknown": "a"}, &codecModel{}); err == nil {
+		t.Errorf("unknown codec should fail")
+	}
+}
+
+func TestOptionsCodec(t *testing.T) {
+	type nested struct {
+		Values map[string]interface{}
+	}
+	origin := &nested{Values: map[string]interface{}{"id": int64(1<<62 + 1)}}
+
+	for _, codec := range []Codec{MsgPackCodec, GobCodec} {
+		result, err := NewEncoder(Options{Codec: codec}).Encode(origin)
+		if err != nil {
+			t.Fatal(err)
+		}
+		target := &nested{}
+		err = NewDecoder(Options{Codec: codec}).Decode(map[string]string{"values": formatValue(result["values"])}, target)
+		if err != nil || target.Values["id"] != int64(1<<62+1) {
+			t.Errorf("codec should keep int64 codec=%T values=%v err=%v", codec, target.Values, err)
+		}
+	}
+
+	RegisterCodec("upper", upperCodec{})
+	type custom struct {
+		Tags []string `redis:"tags;codec=upper"`
+	}
+	result, err := Model2map(&custom{Tags: []string{"a"}})
+	if err != nil || string(result["tags"].([]byte)) != "A" {
+		t.Errorf("custom codec err result=%v err=%v", result, err)
+	}
+}
+
+// 测试用的编解码，字符串切片按 , 拼接并转为大写
+type upperCodec struct{}
+
+func (upperCodec) Marshal(v interface{}) ([]byte, error) {
+	return []byte(strings.ToUpper(strings.Join(v.([]string), ","))), nil
+}
+
+func (upperCodec) Unmarshal(data []byte, v interface{}) error {
+	*v.(*[]string) = strings.Split(string(data), ",")
+	return nil
+}
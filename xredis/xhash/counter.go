@@ -0,0 +1,89 @@
+package xhash
+
+import (
+	"fmt"
+	"github.com/pkg/errors"
+	"reflect"
+)
+
+// ----------------------------------------
+// 计数字段，配合 HINCRBY / HINCRBYFLOAT 使用，例:
+//
+//	counter, err := xhash.Counter(user, "Score")
+//	value, err := client.HIncrByFloat(ctx, key, counter.Key, 1.5).Result()
+// ----------------------------------------
+
+// CounterField 可以原子增减的数值字段
+type CounterField struct {
+	Key   string // hash 中的名称
+	Path  string // Go 字段路径，内嵌结构体以 . 分隔
+	Float bool   // 是否为浮点数，浮点数使用 HINCRBYFLOAT，整数使用 HINCRBY
+}
+
+// Counter 按 Go 字段名查找计数字段，使用默认配置
+func Counter(target interface{}, fieldName string) (*CounterField, error) {
+	return defaultDecoder.Counter(target, fieldName)
+}
+
+// FieldName 按字段指针查找 Go 字段路径，使用默认配置，例: xhash.FieldName(user, &user.Score)
+func FieldName(target interface{}, fieldPtr interface{}) (string, error) {
+	return defaultDecoder.FieldName(target, fieldPtr)
+}
+
+// Counter 按 Go 字段名查找计数字段，字段需要是整数或浮点数（及其指针），
+// 注册了转换器或实现了 Marshaler 的类型存储格式不确定，不能作为计数字段
+func (dec *Decoder) Counter(target interface{}, fieldName string) (*CounterField, error) {
+	targetValue, err := structValue(target)
+	if err != nil {
+		return nil, err
+	}
+	fields, err := dec.engine.cachedStructInfo(targetValue.Type()).selectFields([]string{fieldName})
+	if err != nil {
+		return nil, err
+	}
+	field := fields[0]
+
+	fieldType := field.field.Type
+	if fieldType.Kind() == reflect.Ptr {
+		fieldType = fieldType.Elem()
+	}
+	if _, ok := dec.engine.registry.lookup(fieldType); ok || hasMarshaler(fieldType) {
+		errMsg := fmt.Sprintf("counter field has custom format name=%s type=%s", field.path, field.field.Type)
+		return nil, errors.New(errMsg)
+	}
+
+	counter := &CounterField{Key: field.name, Path: field.path}
+	switch fieldType.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+	case reflect.Float32, reflect.Float64:
+		counter.Float = true
+	default:
+		errMsg := fmt.Sprintf("counter field must be numeric name=%s type=%s", field.path, field.field.Type)
+		return nil, errors.New(errMsg)
+	}
+	return counter, nil
+}
+
+// FieldName 按字段指针查找 Go 字段路径，fieldPtr 需要指向 target 中的字段
+func (dec *Decoder) FieldName(target interface{}, fieldPtr interface{}) (string, error) {
+	targetValue, err := structValue(target)
+	if err != nil {
+		return "", err
+	}
+	ptrValue := reflect.ValueOf(fieldPtr)
+	if ptrValue.Kind() != reflect.Ptr || ptrValue.IsNil() {
+		return "", errors.New("field pointer must be a non-nil pointer")
+	}
+
+	for _, field := range dec.engine.cachedStructInfo(targetValue.Type()).fields {
+		fieldValue, ok := fieldByIndex(targetValue, field.index, false)
+		if !ok {
+			continue
+		}
+		if fieldValue.Type() == ptrValue.Type().Elem() && fieldValue.Addr().Pointer() == ptrValue.Pointer() {
+			return field.path, nil
+		}
+	}
+	return "", errors.New("field pointer does not point to a field of target")
+}
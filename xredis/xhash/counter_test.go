@@ -0,0 +1,49 @@
+package xhash
+
+import (
+	"testing"
+	"time"
+)
+
+type counterUser struct {
+	EmbeddedBase
+	Score   float64
+	Visits  *uint32 `redis:"pv"`
+	Name    string
+	Created time.Time
+}
+
+func TestCounter(t *testing.T) {
+	user := new(counterUser)
+	counter, err := Counter(user, "Score")
+	if err != nil || counter.Key != "score" || !counter.Float {
+		t.Errorf("float counter err counter=%v err=%v", counter, err)
+	}
+	counter, err = Counter(user, "Visits")
+	if err != nil || counter.Key != "pv" || counter.Float {
+		t.Errorf("int counter err counter=%v err=%v", counter, err)
+	}
+
+	for _, name := range []string{"Name", "Created", "Unknown"} {
+		if _, err := Counter(user, name); err == nil {
+			t.Errorf("counter should fail name=%s", name)
+		}
+	}
+}
+
+func TestFieldName(t *testing.T) {
+	user := new(counterUser)
+	name, err := FieldName(user, &user.Score)
+	if err != nil || name != "Score" {
+		t.Errorf("field name err name=%s err=%v", name, err)
+	}
+	name, err = FieldName(user, &user.Id)
+	if err != nil || name != "EmbeddedBase.Id" {
+		t.Errorf("embedded field name err name=%s err=%v", name, err)
+	}
+
+	other := new(counterUser)
+	if _, err := FieldName(user, &other.Score); err == nil {
+		t.Errorf("field of other target should fail")
+	}
+}
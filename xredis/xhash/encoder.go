@@ -0,0 +1,143 @@
+package xhash
+
+import (
+	"sort"
+	"time"
+)
+
+const (
+	// DefaultTimeLayout 时间默认的存储格式
+	DefaultTimeLayout = "2006-01-02 15:04:05"
+
+	// DefaultNullMarker NilMarker 时默认写入的标记
+	DefaultNullMarker = "\x00nil"
+)
+
+// NilPolicy 值为 nil 的字段（nil 指针、nil interface）如何存储
+type NilPolicy int
+
+const (
+	// NilAsEmpty 默认，map 中的值为 nil，go-redis 会写入空字符串，读取时无法还原
+	NilAsEmpty NilPolicy = iota
+
+	// NilSkip 不写入该字段，hash 中原有的值保留
+	NilSkip
+
+	// NilDelete 不写入该字段，通过 EncodeChanges 返回，由调用方用 HDEL 删除
+	NilDelete
+
+	// NilMarker 写入 NullMarker，读取时还原为 nil
+	NilMarker
+)
+
+// NamingStrategy 字段名转存储名称的规则，tag 中未指定名称时使用，内置规则见 naming.go
+type NamingStrategy func(name string) string
+
+// Options 转换的配置，零值字段使用默认配置
+type Options struct {
+	TagKey     string         // tag 的名称，默认 XHashTag
+	Naming     NamingStrategy // 存储名称的规则，默认 Hump2underline，模型级别的 naming 选项优先
+	TimeLayout string         // 时间的存储格式，可以是 layout 或 TimeFormatUnix 等，默认 DefaultTimeLayout
+	Location   *time.Location // 时间的时区，默认 time.Local
+	Codec      Codec          // 嵌套值的编解码，默认 JSONCodec
+	Strict     bool           // 严格模式，hash 中存在结构体没有的字段时报错
+	Registry   *Registry      // 转换器注册表，默认 DefaultRegistry
+	NilPolicy  NilPolicy      // 值为 nil 的字段如何存储，默认 NilAsEmpty
+	NullMarker string         // NilMarker 时写入的标记，默认 DefaultNullMarker
+
+	// CollectErrors 解析时收集所有失败的字段并返回 FieldErrors，其余字段照常填充
+	// 默认遇到错误直接返回 *FieldError
+	CollectErrors bool
+
+	// NoGenerated 不使用 cmd/xhashgen 生成的方法，总是使用反射转换
+	NoGenerated bool
+
+	// SharedNames 最外层同名的字段都保留，读取时都填充同一个值，写入时后面的字段覆盖前面的，兼容旧的数据
+	// 默认同名字段按 encoding/json 的规则取舍
+	SharedNames bool
+}
+
+// withDefaults 填充默认配置
+func (opts Options) withDefaults() Options {
+	if opts.TagKey == "" {
+		opts.TagKey = XHashTag
+	}
+	if opts.Naming == nil {
+		opts.Naming = Hump2underline
+	}
+	if opts.TimeLayout == "" {
+		opts.TimeLayout = DefaultTimeLayout
+	}
+	if opts.Location == nil {
+		opts.Location = time.Local
+	}
+	if opts.Codec == nil {
+		opts.Codec = JSONCodec
+	}
+	if opts.Registry == nil {
+		opts.Registry = DefaultRegistry
+	}
+	if opts.NullMarker == "" {
+		opts.NullMarker = DefaultNullMarker
+	}
+	return opts
+}
+
+// Encoder 模型转 map，存储 hash 数据时用，可以并发使用
+type Encoder struct {
+	engine *engine
+}
+
+// NewEncoder 按配置创建 Encoder，结构体信息按 Encoder 各自缓存，应当复用
+func NewEncoder(opts Options) *Encoder {
+	return &Encoder{engine: newEngine(opts)}
+}
+
+// Encode 模型转 map，origin 为结构体指针
+// 配置了 NilDelete 时，值为 nil 的字段不在 map 中，需要删除时使用 EncodeChanges
+func (enc *Encoder) Encode(origin interface{}) (map[string]interface{}, error) {
+	result, _, err := enc.engine.model2map(origin)
+	return result, err
+}
+
+// EncodeFields 只转换指定的 Go 字段，内嵌结构体中的字段可以用提升后的名称，
+// 指定的字段即使带有 omitempty 也会转换
+func (enc *Encoder) EncodeFields(origin interface{}, fieldNames ...string) (map[string]interface{}, error) {
+	if len(fieldNames) == 0 {
+		return map[string]interface{}{}, nil
+	}
+	result, _, err := enc.engine.model2map(origin, fieldNames...)
+	return result, err
+}
+
+// EncodeChanges 模型转成需要写入及删除的字段，fieldNames 为空时转换所有字段
+// 配置了 NilDelete 时，值为 nil 的字段放在 Del 中，其他配置 Del 总是为空
+func (enc *Encoder) EncodeChanges(origin interface{}, fieldNames ...string) (*Changes, error) {
+	result, nils, err := enc.engine.model2map(origin, fieldNames...)
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(nils)
+	return &Changes{Set: result, Del: nils}, nil
+}
+
+// Decoder map 转模型，取得 hash 数据时用，可以并发使用
+type Decoder struct {
+	engine *engine
+}
+
+// NewDecoder 按配置创建 Decoder，结构体信息按 Decoder 各自缓存，应当复用
+func NewDecoder(opts Options) *Decoder {
+	return &Decoder{engine: newEngine(opts)}
+}
+
+// Decode map 转模型，target 为结构体指针
+func (dec *Decoder) Decode(origin map[string]string, target interface{}) error {
+	return dec.engine.map2model(origin, target)
+}
+
+// 包级别的 Map2model / Model2map 使用默认配置，与 DefaultRegistry 共用缓存
+var (
+	defaultEncoder *Encoder
+	defaultDecoder *Decoder
+)
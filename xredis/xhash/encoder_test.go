@@ -0,0 +1,117 @@
+package xhash
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+// 在 json 外面包一层，用来确认使用了指定的编解码
+type wrapCodec struct{}
+
+func (wrapCodec) Marshal(v interface{}) ([]byte, error) {
+	data, err := json.Marshal(v)
+	return append([]byte("wrap:"), data...), err
+}
+
+func (wrapCodec) Unmarshal(data []byte, v interface{}) error {
+	return json.Unmarshal([]byte(strings.TrimPrefix(string(data), "wrap:")), v)
+}
+
+func TestEncoderOptions(t *testing.T) {
+	type model struct {
+		UserName  string `hash:"name"`
+		Score     int
+		Tags      []string
+		CreatedAt time.Time
+	}
+
+	location := time.FixedZone("UTC+8", 8*3600)
+	opts := Options{
+		TagKey:     "hash",
+		Naming:     strings.ToUpper,
+		TimeLayout: time.RFC3339,
+		Location:   location,
+		Codec:      wrapCodec{},
+	}
+	createdAt := time.Date(2019, 5, 23, 2, 20, 30, 0, time.UTC)
+	origin := &model{UserName: "william", Score: 10, Tags: []string{"man"}, CreatedAt: createdAt}
+
+	data, err := NewEncoder(opts).Encode(origin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data["name"] != "william" || data["SCORE"] != int64(10) {
+		t.Errorf("encoder naming err data=%v", data)
+	}
+	if string(data["TAGS"].([]byte)) != `wrap:["man"]` {
+		t.Errorf("encoder codec err data=%v", data)
+	}
+	if data["CREATEDAT"] != "2019-05-23T10:20:30+08:00" {
+		t.Errorf("encoder time err data=%v", data)
+	}
+
+	hash := map[string]string{
+		"name":      "william",
+		"SCORE":     "10",
+		"TAGS":      `wrap:["man"]`,
+		"CREATEDAT": "2019-05-23T10:20:30+08:00",
+	}
+	result := new(model)
+	if err := NewDecoder(opts).Decode(hash, result); err != nil {
+		t.Fatal(err)
+	}
+	if result.UserName != "william" || result.Score != 10 || result.Tags[0] != "man" {
+		t.Errorf("decoder value err result=%+v", result)
+	}
+	if !result.CreatedAt.Equal(createdAt) {
+		t.Errorf("decoder time err time=%s", result.CreatedAt)
+	}
+}
+
+func TestDecoderStrict(t *testing.T) {
+	type model struct {
+		Id   int64
+		Name string
+	}
+	hash := map[string]string{"id": "1", "name": "william", "age": "10"}
+
+	// 默认忽略结构体没有的字段
+	if err := NewDecoder(Options{}).Decode(hash, new(model)); err != nil {
+		t.Fatal(err)
+	}
+
+	err := NewDecoder(Options{Strict: true}).Decode(hash, new(model))
+	fieldErr, ok := err.(*FieldError)
+	if !ok || fieldErr.Err != ErrUnknownField || fieldErr.Key != "age" {
+		t.Errorf("strict decoder err err=%v", err)
+	}
+}
+
+func TestEncodeFields(t *testing.T) {
+	type model struct {
+		EmbeddedBase
+		Score int `redis:";omitempty"`
+		Name  string
+	}
+	enc := NewEncoder(Options{})
+	origin := &model{EmbeddedBase: EmbeddedBase{Id: 1}, Name: "william"}
+
+	data, err := enc.EncodeFields(origin, "Score", "EmbeddedBase.Id")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(data) != 2 || data["score"] != int64(0) || data["id"] != int64(1) {
+		t.Errorf("encode fields err data=%v", data)
+	}
+
+	data, err = enc.EncodeFields(origin, "Id")
+	if err != nil || len(data) != 1 || data["id"] != int64(1) {
+		t.Errorf("encode promoted field err data=%v err=%v", data, err)
+	}
+
+	if _, err := enc.EncodeFields(origin, "Unknown"); err == nil {
+		t.Error("unknown field should return err")
+	}
+}
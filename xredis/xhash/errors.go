@@ -0,0 +1,103 @@
+package xhash
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+var (
+	// ErrRequired 带 required 选项的字段在 hash 中不存在
+	ErrRequired = errors.New("required field missing")
+
+	// ErrUnknownField 严格模式下 hash 中存在结构体没有的字段
+	ErrUnknownField = errors.New("unknown field")
+)
+
+// FieldError 解析某个字段失败时的错误，可以定位到具体的 hash 及字段
+type FieldError struct {
+	Struct string // 结构体类型，例: model.User
+	Field  string // Go 字段路径，内嵌结构体以 . 分隔，例: BaseModel.Id；hash 中多余的字段为空
+	Key    string // hash 中的字段名称
+	Value  string // hash 中的原始值
+	Err    error  // 原始错误
+}
+
+func (e *FieldError) Error() string {
+	return fmt.Sprintf("decode field failed type=%s field=%s key=%s value=%q: %v", e.Struct, e.Field, e.Key, e.Value, e.Err)
+}
+
+// Unwrap 支持 errors.Is / errors.As
+func (e *FieldError) Unwrap() error {
+	return e.Err
+}
+
+// Cause 支持 github.com/pkg/errors 的 errors.Cause
+func (e *FieldError) Cause() error {
+	return e.Err
+}
+
+// FieldErrors 配置了 CollectErrors 时，所有解析失败的字段
+type FieldErrors []*FieldError
+
+func (e FieldErrors) Error() string {
+	msgs := make([]string, 0, len(e))
+	for _, err := range e {
+		msgs = append(msgs, err.Error())
+	}
+	return fmt.Sprintf("%d fields decode failed: %s", len(e), strings.Join(msgs, "; "))
+}
+
+// Unwrap 支持 Go 1.20 及以上的 errors.Is / errors.As，任意一个字段的错误匹配即可
+func (e FieldErrors) Unwrap() []error {
+	errs := make([]error, len(e))
+	for i, err := range e {
+		errs[i] = err
+	}
+	return errs
+}
+
+// Is 支持 Go 1.20 以下的 errors.Is
+func (e FieldErrors) Is(target error) bool {
+	for _, err := range e {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// As 支持 Go 1.20 以下的 errors.As
+func (e FieldErrors) As(target interface{}) bool {
+	for _, err := range e {
+		if errors.As(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// InvalidTargetError 转换的对象不是结构体指针，例: 传了结构体值、nil 指针、map 指针
+type InvalidTargetError struct {
+	Type reflect.Type
+}
+
+func (e *InvalidTargetError) Error() string {
+	if e.Type == nil {
+		return "target must be a non-nil pointer to struct, got nil"
+	}
+	if e.Type.Kind() == reflect.Ptr && e.Type.Elem().Kind() == reflect.Struct {
+		return "target must be a non-nil pointer to struct, got nil " + e.Type.String()
+	}
+	return "target must be a non-nil pointer to struct, got " + e.Type.String()
+}
+
+// structValue 检查并取出结构体指针指向的值
+func structValue(target interface{}) (reflect.Value, error) {
+	value := reflect.ValueOf(target)
+	if value.Kind() != reflect.Ptr || value.IsNil() || value.Elem().Kind() != reflect.Struct {
+		return reflect.Value{}, &InvalidTargetError{Type: reflect.TypeOf(target)}
+	}
+	return value.Elem(), nil
+}
@@ -0,0 +1,67 @@
+package xhash
+
+import (
+	"errors"
+	"strconv"
+	"testing"
+)
+
+type errorsBase struct {
+	Id int64
+}
+
+func TestFieldError(t *testing.T) {
+	type model struct {
+		errorsBase
+		Name  string `redis:";required"`
+		Score int
+	}
+	hash := map[string]string{"id": "abc", "score": "10"}
+
+	// 默认遇到错误直接返回
+	err := Map2model(hash, new(model))
+	fieldErr, ok := err.(*FieldError)
+	if !ok {
+		t.Fatalf("err should be *FieldError err=%v", err)
+	}
+	if fieldErr.Struct != "xhash.model" || fieldErr.Field != "errorsBase.Id" || fieldErr.Key != "id" || fieldErr.Value != "abc" {
+		t.Errorf("field err value err err=%+v", fieldErr)
+	}
+	if numErr, ok := fieldErr.Err.(*strconv.NumError); !ok || numErr.Err != strconv.ErrSyntax {
+		t.Errorf("field err cause err cause=%v", fieldErr.Err)
+	}
+
+	// 收集所有错误，其余字段照常填充
+	result := new(model)
+	err = NewDecoder(Options{CollectErrors: true}).Decode(hash, result)
+	fieldErrs, ok := err.(FieldErrors)
+	if !ok || len(fieldErrs) != 2 {
+		t.Fatalf("err should be FieldErrors err=%v", err)
+	}
+	if fieldErrs[0].Key != "id" || fieldErrs[1].Key != "name" || fieldErrs[1].Err != ErrRequired {
+		t.Errorf("field errs value err errs=%v", fieldErrs)
+	}
+	if result.Score != 10 {
+		t.Errorf("other fields should be filled result=%+v", result)
+	}
+	if !errors.Is(err, ErrRequired) || !errors.Is(err, strconv.ErrSyntax) || errors.Is(err, ErrUnknownField) {
+		t.Errorf("field errs should support errors.Is err=%v", err)
+	}
+	var target *FieldError
+	if !errors.As(err, &target) || target.Key != "id" {
+		t.Errorf("field errs should support errors.As target=%v", target)
+	}
+	var numErr *strconv.NumError
+	if !errors.As(err, &numErr) || numErr.Num != "abc" {
+		t.Errorf("field errs should support errors.As cause=%v", numErr)
+	}
+	if len(fieldErrs.Unwrap()) != 2 {
+		t.Errorf("field errs unwrap err errs=%v", fieldErrs.Unwrap())
+	}
+
+	// 没有错误时返回 nil
+	hash = map[string]string{"id": "1", "name": "william"}
+	if err := NewDecoder(Options{CollectErrors: true}).Decode(hash, new(model)); err != nil {
+		t.Errorf("no err expected err=%v", err)
+	}
+}
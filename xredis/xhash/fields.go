@@ -0,0 +1,82 @@
+package xhash
+
+import (
+	"fmt"
+	"github.com/pkg/errors"
+)
+
+// ----------------------------------------
+// 只读取部分字段，配合 HMGET 使用，例:
+//
+//	keys, err := xhash.FieldKeys(view)
+//	values, err := client.HMGet(ctx, key, keys...).Result()
+//	err = xhash.Values2model(values, view)
+// ----------------------------------------
+
+// FieldKeys 结构体字段在 hash 中的名称，使用默认配置
+func FieldKeys(target interface{}, fieldNames ...string) ([]string, error) {
+	return defaultDecoder.FieldKeys(target, fieldNames...)
+}
+
+// Values2model 将 HMGET 的结果转模型，使用默认配置
+func Values2model(values []interface{}, target interface{}, fieldNames ...string) error {
+	return defaultDecoder.DecodeValues(values, target, fieldNames...)
+}
+
+// FieldKeys 结构体字段在 hash 中的名称，fieldNames 为 Go 字段名，为空时返回所有字段
+// 多个字段对应同一个名称时只返回一次
+func (dec *Decoder) FieldKeys(target interface{}, fieldNames ...string) ([]string, error) {
+	targetValue, err := structValue(target)
+	if err != nil {
+		return nil, err
+	}
+	fields, err := dec.engine.cachedStructInfo(targetValue.Type()).selectFields(fieldNames)
+	if err != nil {
+		return nil, err
+	}
+	return fieldKeys(fields), nil
+}
+
+// DecodeFields 只将指定的 Go 字段从 map 填充到模型，required 与 default 也只对指定的字段生效
+func (dec *Decoder) DecodeFields(origin map[string]string, target interface{}, fieldNames ...string) error {
+	if len(fieldNames) == 0 {
+		return nil
+	}
+	return dec.engine.map2model(origin, target, fieldNames...)
+}
+
+// DecodeValues 将 HMGET 的结果转模型，values 与 FieldKeys 返回的名称一一对应，
+// fieldNames 需要与调用 FieldKeys 时一致，nil 视为 hash 中没有该字段
+func (dec *Decoder) DecodeValues(values []interface{}, target interface{}, fieldNames ...string) error {
+	keys, err := dec.FieldKeys(target, fieldNames...)
+	if err != nil {
+		return err
+	}
+	if len(keys) != len(values) {
+		errMsg := fmt.Sprintf("values count mismatch keys=%d values=%d", len(keys), len(values))
+		return errors.New(errMsg)
+	}
+
+	origin := make(map[string]string, len(keys))
+	for i, value := range values {
+		if value == nil {
+			continue
+		}
+		origin[keys[i]] = formatValue(value)
+	}
+	return dec.engine.map2model(origin, target, fieldNames...)
+}
+
+// fieldKeys 字段在 hash 中的名称，去重并保持顺序
+func fieldKeys(fields []*fieldInfo) []string {
+	keys := make([]string, 0, len(fields))
+	seen := make(map[string]bool, len(fields))
+	for _, field := range fields {
+		if seen[field.name] {
+			continue
+		}
+		seen[field.name] = true
+		keys = append(keys, field.name)
+	}
+	return keys
+}
@@ -0,0 +1,70 @@
+package xhash
+
+import (
+	"testing"
+)
+
+type fieldsUser struct {
+	Id     int64
+	Name   string `redis:";required"`
+	Score  int    `redis:";default=10"`
+	Number int    `redis:"score"`
+}
+
+func TestFieldKeys(t *testing.T) {
+	// Score 与 Number 共用同一个 hash 字段
+	sharedDecoder := NewDecoder(Options{SharedNames: true})
+	keys, err := sharedDecoder.FieldKeys(new(fieldsUser))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 同名的字段只返回一次
+	if len(keys) != 3 || keys[0] != "id" || keys[1] != "name" || keys[2] != "score" {
+		t.Errorf("field keys err keys=%v", keys)
+	}
+
+	keys, err = sharedDecoder.FieldKeys(new(fieldsUser), "Number", "Id")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(keys) != 2 || keys[0] != "score" || keys[1] != "id" {
+		t.Errorf("field keys with names err keys=%v", keys)
+	}
+
+	if _, err := sharedDecoder.FieldKeys(new(fieldsUser), "Unknown"); err == nil {
+		t.Error("unknown field should return err")
+	}
+}
+
+func TestValues2model(t *testing.T) {
+	sharedDecoder := NewDecoder(Options{SharedNames: true})
+	// nil 视为没有该字段，required 报错
+	result := new(fieldsUser)
+	err := sharedDecoder.DecodeValues([]interface{}{"1", nil, "20"}, result)
+	if fieldErr, ok := err.(*FieldError); !ok || fieldErr.Err != ErrRequired {
+		t.Errorf("nil value should be missing err=%v", err)
+	}
+
+	result = new(fieldsUser)
+	err = sharedDecoder.DecodeValues([]interface{}{"1", "william", nil}, result)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Id != 1 || result.Name != "william" || result.Score != 10 || result.Number != 0 {
+		t.Errorf("values decode err result=%+v", result)
+	}
+
+	// 只处理指定的字段，其他字段的 required 不生效
+	result = new(fieldsUser)
+	err = sharedDecoder.DecodeValues([]interface{}{"30", nil}, result, "Number", "Id")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Number != 30 || result.Id != 0 || result.Score != 0 {
+		t.Errorf("values decode with names err result=%+v", result)
+	}
+
+	if err := sharedDecoder.DecodeValues([]interface{}{"1"}, new(fieldsUser)); err == nil {
+		t.Error("values count mismatch should return err")
+	}
+}
@@ -0,0 +1,174 @@
+package xhash
+
+import (
+	"reflect"
+	"strconv"
+	"time"
+)
+
+// ----------------------------------------
+// cmd/xhashgen 生成的方法，不使用反射，规则与 Model2map / Map2model 一致
+// 使用默认配置且字段类型没有注册转换器时，Encoder / Decoder 转换所有字段会自动使用
+// ----------------------------------------
+
+// HashMarshaler 生成的模型转 map 的方法
+type HashMarshaler interface {
+	ToRedisHash() (map[string]interface{}, error)
+}
+
+// HashUnmarshaler 生成的 map 转模型的方法
+type HashUnmarshaler interface {
+	FromRedisHash(origin map[string]string) error
+}
+
+var (
+	hashMarshalerType   = reflect.TypeOf((*HashMarshaler)(nil)).Elem()
+	hashUnmarshalerType = reflect.TypeOf((*HashUnmarshaler)(nil)).Elem()
+)
+
+// FieldMeta 分析后的字段信息，供代码生成使用
+type FieldMeta struct {
+	Name  string   // 字段在 hash 中的名称
+	Index []int    // 字段的索引路径
+	Path  string   // Go 字段路径，内嵌结构体以 . 分隔
+	Tag   FieldTag // 分析后的 tag
+	Codec string   // 嵌套值的编解码名称，tag 中的 codec 选项或 protobuf 消息的 proto，为空时为默认的 json
+}
+
+// DescribeStruct 按默认配置分析结构体的字段，结果与 Model2map / Map2model 使用的一致，
+// 不受 DefaultRegistry 中注册的转换器影响
+func DescribeStruct(t reflect.Type) ([]FieldMeta, error) {
+	if t.Kind() != reflect.Struct {
+		return nil, &InvalidTargetError{Type: t}
+	}
+	e := newEngine(Options{Registry: NewRegistry()})
+	info := e.cachedStructInfo(t)
+	if info.namingErr != nil {
+		return nil, info.namingErr
+	}
+	metas := make([]FieldMeta, 0, len(info.fields))
+	for _, field := range info.fields {
+		metas = append(metas, FieldMeta{
+			Name:  field.name,
+			Index: field.index,
+			Path:  field.path,
+			Tag:   *field.tag,
+			Codec: nestedCodecName(field.field.Type, field.tag),
+		})
+	}
+	return metas, nil
+}
+
+// generatedFor 类型是否有生成的方法，字段类型注册了转换器时生成的方法不再适用
+func (e *engine) generatedFor(t reflect.Type, fields []*fieldInfo) bool {
+	ptr := reflect.PtrTo(t)
+	if !ptr.Implements(hashMarshalerType) || !ptr.Implements(hashUnmarshalerType) {
+		return false
+	}
+	for _, field := range fields {
+		for fieldType := field.field.Type; ; fieldType = fieldType.Elem() {
+			if _, ok := e.registry.lookup(fieldType); ok {
+				return false
+			}
+			if fieldType.Kind() != reflect.Ptr {
+				break
+			}
+		}
+	}
+	return true
+}
+
+// canUseGenerated 生成的方法只按默认配置生成
+func (opts Options) canUseGenerated() bool {
+	return !opts.NoGenerated &&
+		(opts.TagKey == "" || opts.TagKey == XHashTag) &&
+		opts.Naming == nil &&
+		(opts.TimeLayout == "" || opts.TimeLayout == DefaultTimeLayout) &&
+		(opts.Location == nil || opts.Location == time.Local) &&
+		(opts.Codec == nil || opts.Codec == JSONCodec) &&
+		!opts.Strict &&
+		opts.NilPolicy == NilAsEmpty &&
+		!opts.CollectErrors &&
+		!opts.SharedNames
+}
+
+// ----------------------------------------
+// 以下供生成的代码使用，解析失败时的错误与反射转换时一致
+// ----------------------------------------
+
+// ParseIntField 按位数解析整数，typeName 为字段的类型，超出范围时用于说明
+func ParseIntField(value string, bits int, typeName string) (int64, error) {
+	intVal, err := strconv.ParseInt(value, 10, bits)
+	if err != nil {
+		return 0, rangeErrorOf(typeName, err)
+	}
+	return intVal, nil
+}
+
+// ParseUintField 按位数解析无符号整数
+func ParseUintField(value string, bits int, typeName string) (uint64, error) {
+	uintVal, err := strconv.ParseUint(value, 10, bits)
+	if err != nil {
+		return 0, rangeErrorOf(typeName, err)
+	}
+	return uintVal, nil
+}
+
+// ParseFloatField 按位数解析浮点数
+func ParseFloatField(value string, bits int, typeName string) (float64, error) {
+	floatVal, err := strconv.ParseFloat(value, bits)
+	if err != nil {
+		return 0, rangeErrorOf(typeName, err)
+	}
+	return floatVal, nil
+}
+
+// MarshalNested 按名称的编解码转换嵌套值，名称为空时使用 JSONCodec，name 为字段在 hash 中的名称
+func MarshalNested(name, codecName string, v interface{}) ([]byte, error) {
+	codec, err := lookupNestedCodec(name, codecName, JSONCodec)
+	if err != nil {
+		return nil, err
+	}
+	return codec.Marshal(v)
+}
+
+// UnmarshalNested 按名称的编解码解析嵌套值
+func UnmarshalNested(name, codecName string, data []byte, v interface{}) error {
+	codec, err := lookupNestedCodec(name, codecName, JSONCodec)
+	if err != nil {
+		return err
+	}
+	return codec.Unmarshal(data, v)
+}
+
+// TimeCodec 时间字段的转换，format 与 location 为 tag 中的值，为空时使用默认配置
+type TimeCodec struct {
+	codec *timeCodec
+	err   error
+}
+
+// NewTimeCodec 创建时间字段的转换，时区错误在使用时返回，与反射转换时一致
+func NewTimeCodec(name, format, location string) *TimeCodec {
+	tag := &FieldTag{Name: name, TimeFormat: format, Location: location}
+	codec, err := DefaultRegistry.engine.newTimeCodec(tag)
+	if err != nil {
+		return &TimeCodec{err: timeLocationError(tag, err)}
+	}
+	return &TimeCodec{codec: codec}
+}
+
+// Encode 时间转成存储的值
+func (c *TimeCodec) Encode(t time.Time) (interface{}, error) {
+	if c.err != nil {
+		return nil, c.err
+	}
+	return c.codec.encode(t), nil
+}
+
+// Decode 解析存储的时间
+func (c *TimeCodec) Decode(value string) (time.Time, error) {
+	if c.err != nil {
+		return time.Time{}, c.err
+	}
+	return c.codec.decode(value)
+}
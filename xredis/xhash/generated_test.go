@@ -0,0 +1,96 @@
+package xhash
+
+import (
+	"reflect"
+	"testing"
+)
+
+// 手写的生成方法，记录调用次数
+type generatedUser struct {
+	Id   int64
+	Name string
+
+	encodes int
+	decodes int
+}
+
+func (u *generatedUser) ToRedisHash() (map[string]interface{}, error) {
+	u.encodes++
+	return map[string]interface{}{"id": u.Id, "name": "generated:" + u.Name}, nil
+}
+
+func (u *generatedUser) FromRedisHash(origin map[string]string) error {
+	u.decodes++
+	u.Name = "generated:" + origin["name"]
+	return nil
+}
+
+type generatedId int64
+
+type generatedConverted struct {
+	Id generatedId
+}
+
+func (g *generatedConverted) ToRedisHash() (map[string]interface{}, error) {
+	return map[string]interface{}{"id": "generated"}, nil
+}
+
+func (g *generatedConverted) FromRedisHash(origin map[string]string) error {
+	return nil
+}
+
+func TestGenerated(t *testing.T) {
+	user := &generatedUser{Id: 1, Name: "hello"}
+	result, err := Model2map(user)
+	if err != nil || user.encodes != 1 || result["name"] != "generated:hello" {
+		t.Errorf("generated encode err result=%v err=%v", result, err)
+	}
+	if err := Map2model(map[string]string{"name": "world"}, user); err != nil || user.decodes != 1 || user.Name != "generated:world" {
+		t.Errorf("generated decode err user=%+v err=%v", user, err)
+	}
+
+	// 指定字段时使用反射
+	result, err = defaultEncoder.EncodeFields(user, "Name")
+	if err != nil || user.encodes != 1 || result["name"] != "generated:world" {
+		t.Errorf("encode fields should use reflection result=%v err=%v", result, err)
+	}
+
+	// NoGenerated 总是使用反射
+	result, err = NewEncoder(Options{NoGenerated: true}).Encode(user)
+	if err != nil || user.encodes != 1 || result["name"] != "generated:world" {
+		t.Errorf("no generated encode err result=%v err=%v", result, err)
+	}
+	if err := NewDecoder(Options{NoGenerated: true}).Decode(map[string]string{"name": "redis"}, user); err != nil || user.decodes != 1 || user.Name != "redis" {
+		t.Errorf("no generated decode err user=%+v err=%v", user, err)
+	}
+
+	// 非默认配置使用反射
+	result, err = NewEncoder(Options{TagKey: "hash"}).Encode(user)
+	if err != nil || user.encodes != 1 || result["name"] != "redis" {
+		t.Errorf("custom options encode err result=%v err=%v", result, err)
+	}
+
+	// 字段类型注册了转换器时使用反射
+	r := NewRegistry()
+	r.Register(reflect.TypeOf(generatedId(0)), func(value interface{}) (string, error) {
+		return "converted", nil
+	}, nil)
+	result, err = r.Model2map(&generatedConverted{Id: 1})
+	if err != nil || result["id"] != "converted" {
+		t.Errorf("registry converter should bypass generated result=%v err=%v", result, err)
+	}
+	result, err = Model2map(&generatedConverted{Id: 1})
+	if err != nil || result["id"] != "generated" {
+		t.Errorf("generated without converter err result=%v err=%v", result, err)
+	}
+}
+
+func TestDescribeStruct(t *testing.T) {
+	metas, err := DescribeStruct(reflect.TypeOf(keyUser{}))
+	if err != nil || len(metas) == 0 {
+		t.Fatalf("describe struct err metas=%v err=%v", metas, err)
+	}
+	if _, err := DescribeStruct(reflect.TypeOf(1)); err == nil {
+		t.Errorf("describe non struct should fail")
+	}
+}
@@ -0,0 +1,57 @@
+package xhash
+
+// Decode map 转成 T，T 需要是结构体类型，否则返回 *InvalidTargetError
+func Decode[T any](origin map[string]string) (T, error) {
+	return DecodeWith[T](defaultDecoder, origin)
+}
+
+// DecodeWith 使用指定的 Decoder 将 map 转成 T
+func DecodeWith[T any](dec *Decoder, origin map[string]string) (T, error) {
+	var result T
+	err := dec.Decode(origin, &result)
+	return result, err
+}
+
+// DecodeSlice 批量将 map 转成 T，任何一个失败都返回错误
+func DecodeSlice[T any](origins []map[string]string) ([]T, error) {
+	return DecodeSliceWith[T](defaultDecoder, origins)
+}
+
+// DecodeSliceWith 使用指定的 Decoder 批量将 map 转成 T
+func DecodeSliceWith[T any](dec *Decoder, origins []map[string]string) ([]T, error) {
+	results := make([]T, len(origins))
+	for i, origin := range origins {
+		if err := dec.Decode(origin, &results[i]); err != nil {
+			return nil, err
+		}
+	}
+	return results, nil
+}
+
+// Encode 将 T 转成 map，T 需要是结构体类型，否则返回 *InvalidTargetError
+func Encode[T any](origin *T) (map[string]interface{}, error) {
+	return EncodeWith(defaultEncoder, origin)
+}
+
+// EncodeWith 使用指定的 Encoder 将 T 转成 map
+func EncodeWith[T any](enc *Encoder, origin *T) (map[string]interface{}, error) {
+	return enc.Encode(origin)
+}
+
+// EncodeSlice 批量将 T 转成 map，任何一个失败都返回错误
+func EncodeSlice[T any](origins []*T) ([]map[string]interface{}, error) {
+	return EncodeSliceWith(defaultEncoder, origins)
+}
+
+// EncodeSliceWith 使用指定的 Encoder 批量将 T 转成 map
+func EncodeSliceWith[T any](enc *Encoder, origins []*T) ([]map[string]interface{}, error) {
+	results := make([]map[string]interface{}, len(origins))
+	for i, origin := range origins {
+		result, err := enc.Encode(origin)
+		if err != nil {
+			return nil, err
+		}
+		results[i] = result
+	}
+	return results, nil
+}
@@ -0,0 +1,82 @@
+package xhash
+
+import (
+	"strings"
+	"testing"
+)
+
+type genericUser struct {
+	Id   int64
+	Name string
+}
+
+func TestGeneric(t *testing.T) {
+	user, err := Decode[genericUser](map[string]string{"id": "1", "name": "william"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.Id != 1 || user.Name != "william" {
+		t.Errorf("generic decode err user=%+v", user)
+	}
+
+	data, err := Encode(&user)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data["id"] != int64(1) || data["name"] != "william" {
+		t.Errorf("generic encode err data=%v", data)
+	}
+
+	users, err := DecodeSlice[genericUser]([]map[string]string{{"id": "1"}, {"id": "2"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(users) != 2 || users[1].Id != 2 {
+		t.Errorf("generic decode slice err users=%+v", users)
+	}
+
+	datas, err := EncodeSlice([]*genericUser{&users[0], &users[1]})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(datas) != 2 || datas[1]["id"] != int64(2) {
+		t.Errorf("generic encode slice err datas=%v", datas)
+	}
+
+	if _, err := DecodeSlice[genericUser]([]map[string]string{{"id": "1"}, {"id": "abc"}}); err == nil {
+		t.Error("generic decode slice should return err")
+	}
+}
+
+func TestInvalidTarget(t *testing.T) {
+	var nilUser *genericUser
+	number := 1
+	cases := []struct {
+		target interface{}
+		got    string
+	}{
+		{nil, "got nil"},
+		{genericUser{}, "got xhash.genericUser"},
+		{nilUser, "got nil *xhash.genericUser"},
+		{&number, "got *int"},
+		{&map[string]string{}, "got *map[string]string"},
+	}
+	for _, c := range cases {
+		err := Map2model(map[string]string{"id": "1"}, c.target)
+		if _, ok := err.(*InvalidTargetError); !ok || !strings.HasSuffix(err.Error(), c.got) {
+			t.Errorf("map2model invalid target err err=%v", err)
+		}
+		_, err = Model2map(c.target)
+		if _, ok := err.(*InvalidTargetError); !ok || !strings.HasSuffix(err.Error(), c.got) {
+			t.Errorf("model2map invalid target err err=%v", err)
+		}
+	}
+
+	// 泛型的类型不是结构体时也返回错误而不是 panic
+	if _, err := Decode[int](map[string]string{}); err == nil {
+		t.Error("generic decode non struct should return err")
+	}
+	if _, err := Decode[*genericUser](map[string]string{}); err == nil {
+		t.Error("generic decode pointer should return err")
+	}
+}
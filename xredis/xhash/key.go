@@ -0,0 +1,162 @@
+package xhash
+
+import (
+	"fmt"
+	"github.com/pkg/errors"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// ErrNoKey 模型既没有实现 Keyer，也没有声明 key 的模板
+var ErrNoKey = errors.New("model has no key pattern")
+
+// Keyer 模型自己生成 key，优先于 tag 中声明的模板
+type Keyer interface {
+	RedisKey() (string, error)
+}
+
+// keySegment key 模板的一段，field 为 nil 时是固定的文本
+type keySegment struct {
+	literal string
+	field   *fieldInfo
+	hashTag bool // 是否用 {} 包裹，作为 Redis Cluster 的 hash tag
+}
+
+// Key 按模型声明的模板生成 key，使用默认配置
+func Key(model interface{}) (string, error) {
+	return defaultEncoder.Key(model)
+}
+
+// Key 按模型声明的模板生成 key，模板中的名称为 hash 中的字段名称
+//
+//	{id}        替换为 id 字段的值
+//	{{shop_id}} 替换为用 {} 包裹的 shop_id 字段的值，作为 Redis Cluster 的 hash tag，让相关的 key 在同一个 slot
+func (enc *Encoder) Key(model interface{}) (string, error) {
+	if keyer, ok := model.(Keyer); ok {
+		return keyer.RedisKey()
+	}
+
+	modelValue, err := structValue(model)
+	if err != nil {
+		return "", err
+	}
+	info := enc.engine.cachedStructInfo(modelValue.Type())
+	if info.namingErr != nil {
+		return "", info.namingErr
+	}
+	if info.keyErr != nil {
+		return "", info.keyErr
+	}
+	if info.key == nil {
+		return "", ErrNoKey
+	}
+
+	var builder strings.Builder
+	for _, segment := range info.key {
+		if segment.field == nil {
+			builder.WriteString(segment.literal)
+			continue
+		}
+		value, err := keyFieldValue(modelValue, segment.field)
+		if err != nil {
+			return "", err
+		}
+		if segment.hashTag {
+			builder.WriteString("{" + value + "}")
+		} else {
+			builder.WriteString(value)
+		}
+	}
+	return builder.String(), nil
+}
+
+// keyFieldValue 字段的值转成 key 中的文本，与写入 hash 的格式一致
+func keyFieldValue(modelValue reflect.Value, field *fieldInfo) (string, error) {
+	fieldValue, ok := fieldByIndex(modelValue, field.index, false)
+	if !ok {
+		errMsg := fmt.Sprintf("key field is nil name=%s", field.path)
+		return "", errors.New(errMsg)
+	}
+	value, err := field.encode(fieldValue)
+	if err != nil {
+		return "", err
+	}
+	if value == nil {
+		errMsg := fmt.Sprintf("key field is nil name=%s", field.path)
+		return "", errors.New(errMsg)
+	}
+	return formatValue(value), nil
+}
+
+// parseKeyPattern 分析 key 模板，模板中的名称需要是结构体中的字段
+func parseKeyPattern(pattern string, info *structInfo) ([]keySegment, error) {
+	var segments []keySegment
+	for pattern != "" {
+		start := strings.Index(pattern, "{")
+		if start < 0 {
+			segments = append(segments, keySegment{literal: pattern})
+			break
+		}
+		if start > 0 {
+			segments = append(segments, keySegment{literal: pattern[:start]})
+		}
+
+		// {{name}} 为 hash tag，{name} 为普通的替换
+		rest := pattern[start:]
+		open, close := "{", "}"
+		hashTag := strings.HasPrefix(rest, "{{")
+		if hashTag {
+			open, close = "{{", "}}"
+		}
+		end := strings.Index(rest, close)
+		if end < 0 {
+			errMsg := fmt.Sprintf("key pattern unclosed brace pattern=%s", pattern)
+			return nil, errors.New(errMsg)
+		}
+		name := rest[len(open):end]
+		field := info.byName(name)
+		if field == nil {
+			errMsg := fmt.Sprintf("key pattern unknown field name=%s", name)
+			return nil, errors.New(errMsg)
+		}
+		segments = append(segments, keySegment{field: field, hashTag: hashTag})
+		pattern = rest[end+len(close):]
+	}
+	return segments, nil
+}
+
+// byName 按 hash 中的字段名称查找字段
+func (info *structInfo) byName(name string) *fieldInfo {
+	for _, field := range info.fields {
+		if field.name == name {
+			return field
+		}
+	}
+	return nil
+}
+
+// formatValue 转换后的值转成文本，格式与 go-redis 写入时一致
+func formatValue(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case []byte:
+		return string(v)
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case uint64:
+		return strconv.FormatUint(v, 10)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		if v {
+			return "1"
+		}
+		return "0"
+	default:
+		return fmt.Sprint(v)
+	}
+}
@@ -0,0 +1,86 @@
+package xhash
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type keyOrder struct {
+	_      struct{} `redis:"key=order:{{shop_id}}:{id}"`
+	Id     int64
+	ShopId uint32
+	Status string `redis:"state"`
+}
+
+type keyUser struct {
+	Id int64
+}
+
+func (u *keyUser) RedisKey() (string, error) {
+	return "custom:user:" + strings.Repeat("x", int(u.Id)), nil
+}
+
+func TestKey(t *testing.T) {
+	key, err := Key(&keyOrder{Id: 7, ShopId: 42})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if key != "order:{42}:7" {
+		t.Errorf("key pattern err key=%s", key)
+	}
+
+	// _ 字段不会写入 hash
+	data, err := Model2map(&keyOrder{Id: 7, ShopId: 42})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(data) != 3 {
+		t.Errorf("model tag field should be ignored data=%v", data)
+	}
+
+	key, err = Key(&keyUser{Id: 2})
+	if err != nil || key != "custom:user:xx" {
+		t.Errorf("keyer err key=%s err=%v", key, err)
+	}
+}
+
+func TestKeyError(t *testing.T) {
+	type noKey struct {
+		Id int64
+	}
+	if _, err := Key(&noKey{}); err != ErrNoKey {
+		t.Errorf("no key pattern err err=%v", err)
+	}
+
+	type unknownField struct {
+		_  struct{} `redis:"key=user:{uid}"`
+		Id int64
+	}
+	if _, err := Key(&unknownField{}); err == nil || !strings.Contains(err.Error(), "unknown field name=uid") {
+		t.Errorf("unknown key field err err=%v", err)
+	}
+
+	type unclosed struct {
+		_  struct{} `redis:"key=user:{id"`
+		Id int64
+	}
+	if _, err := Key(&unclosed{}); err == nil || !strings.Contains(err.Error(), "unclosed brace") {
+		t.Errorf("unclosed key pattern err err=%v", err)
+	}
+
+	type nilField struct {
+		_  struct{} `redis:"key=user:{id}"`
+		Id *int64
+	}
+	if _, err := Key(&nilField{}); err == nil || !strings.Contains(err.Error(), "key field is nil") {
+		t.Errorf("nil key field err err=%v", err)
+	}
+}
+
+func TestParseModelTag(t *testing.T) {
+	tag := ParseModelTag(reflect.TypeOf(keyOrder{}))
+	if tag.Key != "order:{{shop_id}}:{id}" {
+		t.Errorf("parse model tag err tag=%+v", tag)
+	}
+}
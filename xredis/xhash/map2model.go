@@ -2,277 +2,261 @@ package xhash
 
 import (
 	"bytes"
-	"encoding/json"
-	"errors"
 	"fmt"
+	"github.com/pkg/errors"
 	"reflect"
 	"strconv"
-	"time"
 )
 
 // Map2model map 转模型，取得 hash 数据时用
 func Map2model(origin map[string]string, target interface{}) error {
+	return defaultDecoder.Decode(origin, target)
+}
 
-	targetValue := reflect.ValueOf(target).Elem()
+// map2model map 转模型，fieldNames 为 Go 字段名，为空时处理所有字段
+// 指定了字段时，required 与 default 也只对指定的字段生效
+func (e *engine) map2model(origin map[string]string, target interface{}, fieldNames ...string) error {
 
-	// 循环处理每一个字段
-	for i := 0; i < targetValue.NumField(); i++ {
-		// 获取 tag
-		field := targetValue.Type().Field(i)
-		tag := ParseTag(field)
-		// 忽略直接跳过
-		if tag.IsIgnore {
-			continue
+	targetValue, err := structValue(target)
+	if err != nil {
+		return err
+	}
+	info := e.cachedStructInfo(targetValue.Type())
+	// 有生成的方法时直接使用
+	if len(fieldNames) == 0 && e.generated && info.generated {
+		return target.(HashUnmarshaler).FromRedisHash(origin)
+	}
+	fields, err := info.selectFields(fieldNames)
+	if err != nil {
+		return err
+	}
+	structName := targetValue.Type().String()
+
+	// 默认遇到错误直接返回，配置了 CollectErrors 时收集所有错误
+	var fieldErrs FieldErrors
+	fail := func(fieldErr *FieldError) error {
+		if !e.opts.CollectErrors {
+			return fieldErr
+		}
+		fieldErrs = append(fieldErrs, fieldErr)
+		return nil
+	}
+
+	// 严格模式，hash 中不能有结构体没有的字段
+	if e.opts.Strict {
+		for key, originVal := range origin {
+			if info.names[key] {
+				continue
+			}
+			err := fail(&FieldError{Struct: structName, Key: key, Value: originVal, Err: ErrUnknownField})
+			if err != nil {
+				return err
+			}
 		}
-		// map 中不包含直接跳过
-		originVal, has := origin[tag.Name]
+	}
+
+	// 循环处理每一个字段
+	for _, field := range fields {
+		originVal, has := origin[field.name]
 		if !has {
+			// 必须包含的字段缺失
+			if field.tag.Required {
+				err := fail(&FieldError{Struct: structName, Field: field.path, Key: field.name, Err: ErrRequired})
+				if err != nil {
+					return err
+				}
+				continue
+			}
+			// map 中不包含又没有默认值，直接跳过
+			if !field.tag.HasDefault {
+				continue
+			}
+			originVal = field.tag.Default
+		}
+		// 内嵌的结构体指针为 nil 时会分配空间
+		fieldValue, _ := fieldByIndex(targetValue, field.index, true)
+		// 配置了 NilMarker 时，标记还原为 nil
+		if e.opts.NilPolicy == NilMarker && originVal == e.opts.NullMarker && isNilable(fieldValue) {
+			fieldValue.Set(reflect.Zero(fieldValue.Type()))
 			continue
 		}
-		err := setValue(targetValue, field, originVal)
+		err := field.decode(fieldValue, originVal)
 		if err != nil {
-			return err
+			err = fail(&FieldError{Struct: structName, Field: field.path, Key: field.name, Value: originVal, Err: err})
+			if err != nil {
+				return err
+			}
 		}
 	}
 
+	if len(fieldErrs) > 0 {
+		return fieldErrs
+	}
 	return nil
 }
 
 // ----------------------------------------
-// 根据字段类型，填充值
+// 根据字段类型，选择填充值的方法
 // ----------------------------------------
-func setValue(targetValue reflect.Value, field reflect.StructField, originVal string) error {
-	fieldValue := targetValue.FieldByName(field.Name)
+func (e *engine) decoderFor(field reflect.StructField, tag *FieldTag) decodeFunc {
+	return e.typeDecoder(field.Name, field.Type, tag)
+}
+
+// stringType 存放到接口中的值的类型
+var stringType = reflect.TypeOf("")
+
+func (e *engine) typeDecoder(name string, t reflect.Type, tag *FieldTag) decodeFunc {
+	// 注册了转换器，指针类型也可以注册
+	if decoder := e.registry.decoder(t); decoder != nil {
+		return decoder
+	}
 	// 指针有专门的处理
-	if field.Type.Kind() == reflect.Ptr {
-		return setPtrValue(targetValue, field, originVal)
+	if t.Kind() == reflect.Ptr {
+		return ptrDecoder(e.typeDecoder(name, t.Elem(), tag))
+	}
+	// 自定义了解析方法
+	if decoder := unmarshalerDecoder(t); decoder != nil {
+		return decoder
 	}
 
-	switch field.Type.Kind() {
+	switch t.Kind() {
 	// 处理所有 Int 类型
 	case reflect.Int64, reflect.Int32, reflect.Int16, reflect.Int8, reflect.Int:
-		return setIntValue(fieldValue, originVal)
+		return setIntValue
 	// 处理所有 Uint 类型
 	case reflect.Uint64, reflect.Uint32, reflect.Uint16, reflect.Uint8, reflect.Uint:
-		return setUintValue(fieldValue, originVal)
+		return setUintValue
 	// 处理字符串类型
 	case reflect.String:
-		return setStrValue(fieldValue, originVal)
+		return setStrValue
 	// 处理布尔类型
 	case reflect.Bool:
-		return setBoolValue(fieldValue, originVal)
+		return setBoolValue
 	// 处理浮点类型
 	case reflect.Float64, reflect.Float32:
-		return setFloatValue(fieldValue, originVal)
+		return setFloatValue
 	// 处理切片类型
 	case reflect.Slice:
-		return setSliceValue(fieldValue, originVal)
+		return e.nestedDecoder(t, tag)
 	// 处理结构体类型
 	case reflect.Struct, reflect.Map:
-		if field.Type.String() == "time.Time" {
-			return setTimeValue(fieldValue, originVal)
+		if t == timeType {
+			return e.timeDecoder(tag)
 		}
-		return setStructValue(fieldValue, originVal)
-	// 处理 interface 类型
+		return e.nestedDecoder(t, tag)
+	// 处理 interface 类型，只有可以存放字符串的接口，例: interface{}
 	case reflect.Interface:
-		fieldValue.Set(reflect.ValueOf(originVal))
-		return nil
-	default:
-		errMsg := fmt.Sprintf("unsupported type name=%s type=%s", field.Name, field.Type)
+		if stringType.AssignableTo(t) {
+			return setInterfaceValue
+		}
+	}
+	errMsg := fmt.Sprintf("unsupported type name=%s type=%s", name, t)
+	return func(reflect.Value, string) error {
 		return errors.New(errMsg)
 	}
 }
 
+// ptrDecoder 指针类型先分配空间，再按指向的类型填充
+func ptrDecoder(elemDecoder decodeFunc) decodeFunc {
+	return func(fieldValue reflect.Value, originVal string) error {
+		ptr := reflect.New(fieldValue.Type().Elem())
+		err := elemDecoder(ptr.Elem(), originVal)
+		if err != nil {
+			return err
+		}
+		fieldValue.Set(ptr)
+		return nil
+	}
+}
+
 func setIntValue(fieldValue reflect.Value, originVal string) error {
-	intVal, err := strconv.ParseInt(originVal, 10, 64)
+	intVal, err := strconv.ParseInt(originVal, 10, fieldValue.Type().Bits())
 	if err != nil {
-		return err
+		return rangeError(fieldValue, err)
 	}
 	fieldValue.SetInt(intVal)
 	return nil
 }
 
 func setUintValue(fieldValue reflect.Value, originVal string) error {
-	uintVal, err := strconv.ParseUint(originVal, 10, 64)
+	uintVal, err := strconv.ParseUint(originVal, 10, fieldValue.Type().Bits())
 	if err != nil {
-		return err
+		return rangeError(fieldValue, err)
 	}
 	fieldValue.SetUint(uintVal)
 	return nil
 }
 
-func setStrValue(fieldValue reflect.Value, originVal string) error {
-	if fieldValue.Type().Kind() == reflect.Ptr {
-		fieldValue.Set(reflect.ValueOf(&originVal))
-	} else {
-		fieldValue.SetString(originVal)
+// isNilable 可以为 nil 的字段
+func isNilable(fieldValue reflect.Value) bool {
+	switch fieldValue.Kind() {
+	case reflect.Ptr, reflect.Interface, reflect.Map, reflect.Slice:
+		return true
 	}
-	return nil
-}
-
-func setBoolValue(fieldValue reflect.Value, originVal string) error {
-	boolVal, err := strconv.ParseBool(originVal)
-	if err != nil {
-		return err
-	}
-	if fieldValue.Type().Kind() == reflect.Ptr {
-		fieldValue.Set(reflect.ValueOf(&boolVal))
-	} else {
-		fieldValue.SetBool(boolVal)
-	}
-	return nil
+	return false
 }
 
-func setFloatValue(fieldValue reflect.Value, originVal string) error {
-	floatVal, err := strconv.ParseFloat(originVal, 64)
-	if err != nil {
-		return err
-	}
-	fieldValue.SetFloat(floatVal)
-	return nil
+// rangeError 按字段实际的位数解析，超出范围时说明字段类型，避免溢出后存入错误的值
+func rangeError(fieldValue reflect.Value, err error) error {
+	return rangeErrorOf(fieldValue.Type().String(), err)
 }
 
-func setSliceValue(fieldValue reflect.Value, originVal string) error {
-	sliceType := reflect.SliceOf(fieldValue.Type().Elem())
-	slice := reflect.New(sliceType)
-	bytesVal := bytes.NewBufferString(originVal).Bytes()
-	err := json.Unmarshal(bytesVal, slice.Interface())
-	if err != nil {
-		return err
+// rangeErrorOf 用 %w 包装，通过 FieldError 仍然可以 errors.Is(err, strconv.ErrRange)
+func rangeErrorOf(typeName string, err error) error {
+	if numErr, ok := err.(*strconv.NumError); ok && numErr.Err == strconv.ErrRange {
+		return fmt.Errorf("value out of range for %s: %w", typeName, err)
 	}
-	fieldValue.Set(reflect.ValueOf(slice.Interface()).Elem())
-	return nil
+	return err
 }
 
-func setTimeValue(fieldValue reflect.Value, originVal string) error {
-	timeTime, err := time.ParseInLocation("2006-01-02 15:04:05", originVal, time.Local)
-	if err != nil {
-		return err
-	}
-	fieldValue.Set(reflect.ValueOf(timeTime))
+func setStrValue(fieldValue reflect.Value, originVal string) error {
+	fieldValue.SetString(originVal)
 	return nil
 }
 
-func setStructValue(fieldValue reflect.Value, originVal string) error {
-	obj := reflect.New(fieldValue.Type())
-	bytesVal := bytes.NewBufferString(originVal).Bytes()
-	err := json.Unmarshal(bytesVal, obj.Interface())
+func setBoolValue(fieldValue reflect.Value, originVal string) error {
+	boolVal, err := strconv.ParseBool(originVal)
 	if err != nil {
 		return err
 	}
-	fieldValue.Set(obj.Elem())
+	fieldValue.SetBool(boolVal)
 	return nil
 }
 
-// ----------------------------------------
-// 根据字段类型，填充值，这个专门负责指针类型
-// ----------------------------------------
-func setPtrValue(targetValue reflect.Value, field reflect.StructField, originVal string) error {
-	fieldValue := targetValue.FieldByName(field.Name)
-	switch fieldValue.Type().Elem().Kind() {
-	// 处理所有 Int 指针类型
-	case reflect.Int64, reflect.Int32, reflect.Int16, reflect.Int8, reflect.Int:
-		return setPtrIntValue(fieldValue, originVal)
-	// 处理所有 Uint 指针类型
-	case reflect.Uint64, reflect.Uint32, reflect.Uint16, reflect.Uint8, reflect.Uint:
-		return setPtrUintValue(fieldValue, originVal)
-	// 处理字符串指针类型
-	case reflect.String:
-		return setStrValue(fieldValue, originVal)
-	// 处理布尔指针类型
-	case reflect.Bool:
-		return setBoolValue(fieldValue, originVal)
-	// 处理布尔指针类型
-	case reflect.Float64, reflect.Float32:
-		return setPtrFloatValue(fieldValue, originVal)
-	// 处理自定义结构体指针
-	default:
-		if fieldValue.Type().Elem().String() == "time.Time" {
-			return setPtrTimeValue(fieldValue, originVal)
-		}
-		return setPtrStructValue(fieldValue, originVal)
-	}
-}
-
-func setPtrIntValue(fieldValue reflect.Value, originVal string) error {
-	intVal, err := strconv.ParseInt(originVal, 10, 64)
+func setFloatValue(fieldValue reflect.Value, originVal string) error {
+	floatVal, err := strconv.ParseFloat(originVal, fieldValue.Type().Bits())
 	if err != nil {
-		return err
-	}
-	switch fieldValue.Type().Elem().Kind() {
-	case reflect.Int64:
-		fieldValue.Set(reflect.ValueOf(&intVal))
-	case reflect.Int32:
-		tmpVal := int32(intVal)
-		fieldValue.Set(reflect.ValueOf(&tmpVal))
-	case reflect.Int16:
-		tmpVal := int16(intVal)
-		fieldValue.Set(reflect.ValueOf(&tmpVal))
-	case reflect.Int8:
-		tmpVal := int8(intVal)
-		fieldValue.Set(reflect.ValueOf(&tmpVal))
-	case reflect.Int:
-		tmpVal := int(intVal)
-		fieldValue.Set(reflect.ValueOf(&tmpVal))
+		return rangeError(fieldValue, err)
 	}
+	fieldValue.SetFloat(floatVal)
 	return nil
 }
 
-func setPtrUintValue(fieldValue reflect.Value, originVal string) error {
-	uintVal, err := strconv.ParseUint(originVal, 10, 64)
-	if err != nil {
-		return err
-	}
-	switch fieldValue.Type().Elem().Kind() {
-	case reflect.Uint64:
-		fieldValue.Set(reflect.ValueOf(&uintVal))
-	case reflect.Uint32:
-		tmpVal := uint32(uintVal)
-		fieldValue.Set(reflect.ValueOf(&tmpVal))
-	case reflect.Uint16:
-		tmpVal := uint16(uintVal)
-		fieldValue.Set(reflect.ValueOf(&tmpVal))
-	case reflect.Uint8:
-		tmpVal := uint8(uintVal)
-		fieldValue.Set(reflect.ValueOf(&tmpVal))
-	case reflect.Uint:
-		tmpVal := uint(uintVal)
-		fieldValue.Set(reflect.ValueOf(&tmpVal))
-	}
+func setInterfaceValue(fieldValue reflect.Value, originVal string) error {
+	fieldValue.Set(reflect.ValueOf(originVal))
 	return nil
 }
 
-func setPtrFloatValue(fieldValue reflect.Value, originVal string) error {
-	floatVal, err := strconv.ParseFloat(originVal, 64)
+// nestedDecoder 嵌套值按字段的编解码解析，切片按元素类型的切片解析
+func (e *engine) nestedDecoder(t reflect.Type, tag *FieldTag) decodeFunc {
+	codec, err := e.nestedCodec(t, tag)
 	if err != nil {
-		return err
+		return func(reflect.Value, string) error {
+			return err
+		}
 	}
-	switch fieldValue.Type().Elem().Kind() {
-	case reflect.Float64:
-		fieldValue.Set(reflect.ValueOf(&floatVal))
-	case reflect.Float32:
-		tmpVal := float32(floatVal)
-		fieldValue.Set(reflect.ValueOf(&tmpVal))
+	valueType := t
+	if t.Kind() == reflect.Slice {
+		valueType = reflect.SliceOf(t.Elem())
 	}
-	return nil
-}
-
-func setPtrTimeValue(fieldValue reflect.Value, originVal string) error {
-	timeTime, err := time.ParseInLocation("2006-01-02 15:04:05", originVal, time.Local)
-	if err != nil {
-		return err
-	}
-	fieldValue.Set(reflect.ValueOf(&timeTime))
-	return nil
-}
-
-func setPtrStructValue(fieldValue reflect.Value, originVal string) error {
-	obj := reflect.New(fieldValue.Type().Elem())
-	bytesVal := bytes.NewBufferString(originVal).Bytes()
-	err := json.Unmarshal(bytesVal, obj.Interface())
-	if err != nil {
-		return err
+	return func(fieldValue reflect.Value, originVal string) error {
+		obj := reflect.New(valueType)
+		bytesVal := bytes.NewBufferString(originVal).Bytes()
+		err := codec.Unmarshal(bytesVal, obj.Interface())
+		if err != nil {
+			return err
+		}
+		fieldValue.Set(obj.Elem())
+		return nil
 	}
-	fieldValue.Set(obj)
-	return nil
 }
@@ -1,7 +1,9 @@
 package xhash
 
 import (
+	"errors"
 	"github.com/stretchr/testify/suite"
+	"strconv"
 	"testing"
 	"time"
 )
@@ -51,7 +53,8 @@ func (s *Map2modelTestSuite) TestInt() {
 		NumberPtrInt64 *int64 `redis:"number"`
 	}
 	result := new(model)
-	err := Map2model(data, result)
+	// 多个最外层字段读取同一个 hash 字段
+	err := NewDecoder(Options{SharedNames: true}).Decode(data, result)
 	s.Nil(err)
 	s.Equal(result.Number, int(1), "test int value err")
 	s.Equal(*result.NumberPtrInt, int(1), "test *int value err")
@@ -74,7 +77,8 @@ func (s *Map2modelTestSuite) TestUint() {
 		NumberPtrUint64 *uint64 `redis:"number"`
 	}
 	result := new(model)
-	err := Map2model(data, result)
+	// 多个最外层字段读取同一个 hash 字段
+	err := NewDecoder(Options{SharedNames: true}).Decode(data, result)
 	s.Nil(err)
 	s.Equal(result.Number, uint(1), "test uint value err")
 	s.Equal(*result.NumberPtrUint, uint(1), "test *uint value err")
@@ -172,7 +176,8 @@ func (s *Map2modelTestSuite) TestTime() {
 		UpdatedAt *time.Time `redis:"date_time"`
 	}
 	result := new(model)
-	err := Map2model(data, result)
+	// 多个最外层字段读取同一个 hash 字段
+	err := NewDecoder(Options{SharedNames: true}).Decode(data, result)
 	s.Nil(err)
 	s.Equal(result.CreatedAt.Year(), 2019, "test time year value err")
 	s.Equal(result.UpdatedAt.Hour(), 10, "test time hour value err")
@@ -192,7 +197,8 @@ func (s *Map2modelTestSuite) TestModel() {
 		Friend User `redis:"user"`
 	}
 	result := new(model)
-	err := Map2model(data, result)
+	// 多个最外层字段读取同一个 hash 字段
+	err := NewDecoder(Options{SharedNames: true}).Decode(data, result)
 	s.Nil(err)
 	s.Equal(result.User.Id, int64(100), "test model value err")
 	s.Equal(result.Friend.Name, "Wade", "test model value err")
@@ -224,6 +230,95 @@ func (s *Map2modelTestSuite) TestNotSupportType() {
 	s.Contains(err.Error(), "unsupported type", "test value err")
 }
 
+// 测试不能存放字符串的接口
+func (s *Map2modelTestSuite) TestNotSupportInterface() {
+	data := make(map[string]string)
+	data["err"] = "1"
+	type model struct {
+		Err error
+	}
+	result := new(model)
+	err := Map2model(data, result)
+	s.NotEmpty(err)
+	s.Contains(err.Error(), "unsupported type", "test interface err")
+	s.Nil(result.Err)
+}
+
+// 测试必须包含的字段
+func (s *Map2modelTestSuite) TestRequired() {
+	data := make(map[string]string)
+	type model struct {
+		Id int64 `redis:";required"`
+	}
+	result := new(model)
+	err := Map2model(data, result)
+	s.NotNil(err)
+	s.Contains(err.Error(), "required field missing", "test required err")
+
+	data["id"] = "1"
+	err = Map2model(data, result)
+	s.Nil(err)
+	s.Equal(result.Id, int64(1), "test required value err")
+}
+
+// 测试默认值
+func (s *Map2modelTestSuite) TestDefault() {
+	data := make(map[string]string)
+	data["name"] = "william"
+	type model struct {
+		Name   string `redis:";default=wade"`
+		Score  *int   `redis:";default=10"`
+		Status int    `redis:";default=abc"`
+	}
+	result := new(model)
+	err := Map2model(data, result)
+	s.NotNil(err, "test bad default err")
+
+	type goodModel struct {
+		Name  string `redis:";default=wade"`
+		Score *int   `redis:";default=10"`
+	}
+	goodResult := new(goodModel)
+	err = Map2model(data, goodResult)
+	s.Nil(err)
+	s.Equal(goodResult.Name, "william", "test default value err")
+	s.Equal(*goodResult.Score, 10, "test default ptr value err")
+}
+
+// 测试超出范围的数值
+func (s *Map2modelTestSuite) TestOutOfRange() {
+	type model struct {
+		Int8     int8
+		Uint8    *uint8
+		Float32  float32
+		Int16Ptr *int16
+	}
+	cases := map[string]string{
+		"int8":      "300",
+		"uint8":     "256",
+		"float32":   "1e40",
+		"int16_ptr": "-40000",
+	}
+	for key, value := range cases {
+		result := new(model)
+		err := Map2model(map[string]string{key: value}, result)
+		s.NotNil(err, "test out of range err key=%s", key)
+		s.Contains(err.Error(), "value out of range", "test out of range err key=%s", key)
+		s.True(errors.Is(err, strconv.ErrRange), "test out of range errors.Is err key=%s", key)
+		var numErr *strconv.NumError
+		s.True(errors.As(err, &numErr), "test out of range errors.As err key=%s", key)
+		s.Equal(*result, model{}, "test out of range value err key=%s", key)
+	}
+
+	// 边界值正常解析
+	result := new(model)
+	err := Map2model(map[string]string{"int8": "-128", "uint8": "255", "float32": "3.4e38", "int16_ptr": "32767"}, result)
+	s.Nil(err)
+	s.Equal(result.Int8, int8(-128), "test int8 value err")
+	s.Equal(*result.Uint8, uint8(255), "test uint8 value err")
+	s.Equal(*result.Int16Ptr, int16(32767), "test int16 value err")
+}
+
 func TestMap2modelSuite(t *testing.T) {
 	suite.Run(t, new(Map2modelTestSuite))
 }
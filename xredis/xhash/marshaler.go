@@ -0,0 +1,89 @@
+package xhash
+
+import (
+	"encoding"
+	"reflect"
+)
+
+// Marshaler 自定义类型存储到 hash 时的格式
+type Marshaler interface {
+	MarshalRedisField() (string, error)
+}
+
+// Unmarshaler 自定义类型从 hash 读取时的解析
+type Unmarshaler interface {
+	UnmarshalRedisField(value string) error
+}
+
+var (
+	marshalerType       = reflect.TypeOf((*Marshaler)(nil)).Elem()
+	unmarshalerType     = reflect.TypeOf((*Unmarshaler)(nil)).Elem()
+	textMarshalerType   = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// ----------------------------------------
+// 优先使用 Marshaler，其次是 encoding.TextMarshaler
+// time.Time 保持原有的格式，不使用 TextMarshaler
+// ----------------------------------------
+func marshalerEncoder(t reflect.Type) encodeFunc {
+	if implements(t, marshalerType) {
+		return func(fieldValue reflect.Value) (interface{}, error) {
+			return addrValue(fieldValue, marshalerType).Interface().(Marshaler).MarshalRedisField()
+		}
+	}
+	if t == timeType {
+		return nil
+	}
+	if implements(t, textMarshalerType) {
+		return func(fieldValue reflect.Value) (interface{}, error) {
+			text, err := addrValue(fieldValue, textMarshalerType).Interface().(encoding.TextMarshaler).MarshalText()
+			if err != nil {
+				return nil, err
+			}
+			return string(text), nil
+		}
+	}
+	return nil
+}
+
+func unmarshalerDecoder(t reflect.Type) decodeFunc {
+	if implements(t, unmarshalerType) {
+		return func(fieldValue reflect.Value, originVal string) error {
+			return fieldValue.Addr().Interface().(Unmarshaler).UnmarshalRedisField(originVal)
+		}
+	}
+	if t == timeType {
+		return nil
+	}
+	if implements(t, textUnmarshalerType) {
+		return func(fieldValue reflect.Value, originVal string) error {
+			return fieldValue.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(originVal))
+		}
+	}
+	return nil
+}
+
+// hasMarshaler 是否自定义了存储格式，这样的内嵌结构体不展开
+func hasMarshaler(t reflect.Type) bool {
+	return implements(t, marshalerType) || implements(t, unmarshalerType) ||
+		implements(t, textMarshalerType) || implements(t, textUnmarshalerType)
+}
+
+// implements 类型本身或其指针实现了接口
+func implements(t reflect.Type, iface reflect.Type) bool {
+	return t.Implements(iface) || reflect.PtrTo(t).Implements(iface)
+}
+
+// addrValue 方法定义在指针上时，取地址后再调用，不可取地址时复制一份
+func addrValue(fieldValue reflect.Value, iface reflect.Type) reflect.Value {
+	if fieldValue.Type().Implements(iface) {
+		return fieldValue
+	}
+	if fieldValue.CanAddr() {
+		return fieldValue.Addr()
+	}
+	ptr := reflect.New(fieldValue.Type())
+	ptr.Elem().Set(fieldValue)
+	return ptr
+}
@@ -0,0 +1,100 @@
+package xhash
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// 以分存储的金额，方法定义在指针上
+type cent struct {
+	value int64
+}
+
+func (c *cent) MarshalRedisField() (string, error) {
+	return fmt.Sprintf("%d.%02d", c.value/100, c.value%100), nil
+}
+
+func (c *cent) UnmarshalRedisField(value string) error {
+	parts := strings.SplitN(value, ".", 2)
+	yuan, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return err
+	}
+	c.value = yuan * 100
+	if len(parts) == 2 {
+		fen, err := strconv.ParseInt(parts[1], 10, 64)
+		if err != nil {
+			return err
+		}
+		c.value += fen
+	}
+	return nil
+}
+
+// 方法定义在值上
+type level int
+
+func (l level) MarshalRedisField() (string, error) {
+	return "lv" + strconv.Itoa(int(l)), nil
+}
+
+func (l *level) UnmarshalRedisField(value string) error {
+	i, err := strconv.Atoi(strings.TrimPrefix(value, "lv"))
+	*l = level(i)
+	return err
+}
+
+func TestMarshaler(t *testing.T) {
+	type model struct {
+		Price    cent
+		Discount *cent
+		Level    level
+		Ip       net.IP
+		Nothing  *cent
+	}
+
+	origin := &model{
+		Price:    cent{value: 1234},
+		Discount: &cent{value: 99},
+		Level:    3,
+		Ip:       net.ParseIP("192.168.1.1"),
+	}
+	data, err := Model2map(origin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data["price"] != "12.34" || data["discount"] != "0.99" || data["level"] != "lv3" {
+		t.Errorf("marshaler value err data=%v", data)
+	}
+	if data["ip"] != "192.168.1.1" {
+		t.Errorf("text marshaler value err data=%v", data)
+	}
+	if data["nothing"] != nil {
+		t.Errorf("nil marshaler value err data=%v", data)
+	}
+
+	hash := map[string]string{
+		"price":    "12.34",
+		"discount": "0.99",
+		"level":    "lv3",
+		"ip":       "192.168.1.1",
+	}
+	result := new(model)
+	if err := Map2model(hash, result); err != nil {
+		t.Fatal(err)
+	}
+	if result.Price.value != 1234 || result.Discount.value != 99 || result.Level != 3 {
+		t.Errorf("unmarshaler value err result=%+v", result)
+	}
+	if !result.Ip.Equal(origin.Ip) {
+		t.Errorf("text unmarshaler value err ip=%s", result.Ip)
+	}
+
+	hash["price"] = "abc"
+	if err := Map2model(hash, new(model)); err == nil {
+		t.Error("unmarshaler should return err")
+	}
+}
@@ -1,7 +1,6 @@
 package xhash
 
 import (
-	"encoding/json"
 	"fmt"
 	"github.com/pkg/errors"
 	"reflect"
@@ -10,124 +9,194 @@ import (
 
 // Model2map 模型转 map，存储 hash 数据时用
 func Model2map(origin interface{}) (map[string]interface{}, error) {
+	return defaultEncoder.Encode(origin)
+}
+
+// model2map 模型转 map，fieldNames 为 Go 字段名，为空时转换所有字段
+// 指定了字段时忽略 omitempty，按指定的写入
+// 配置了 NilDelete 时，值为 nil 的字段不在 map 中，名称通过 nils 返回
+func (e *engine) model2map(origin interface{}, fieldNames ...string) (result map[string]interface{}, nils []string, err error) {
 
-	originValue := reflect.ValueOf(origin).Elem()
+	originValue, err := structValue(origin)
+	if err != nil {
+		return nil, nil, err
+	}
+	info := e.cachedStructInfo(originValue.Type())
+	// 有生成的方法时直接使用
+	if len(fieldNames) == 0 && e.generated && info.generated {
+		result, err = origin.(HashMarshaler).ToRedisHash()
+		return result, nil, err
+	}
+	fields, err := info.selectFields(fieldNames)
+	if err != nil {
+		return nil, nil, err
+	}
 
 	// 循环处理每一个字段
-	result := make(map[string]interface{})
-	for i := 0; i < originValue.NumField(); i++ {
-		// 获取 tag
-		field := originValue.Type().Field(i)
-		tag := ParseTag(field)
-		// 忽略直接跳过
-		if tag.IsIgnore {
+	result = make(map[string]interface{}, len(fields))
+	for _, field := range fields {
+		// 内嵌的结构体指针为 nil 时，其中的字段都不存储
+		fieldValue, ok := fieldByIndex(originValue, field.index, false)
+		if !ok {
+			continue
+		}
+		// 零值不存储
+		if field.tag.OmitEmpty && len(fieldNames) == 0 && isEmptyValue(fieldValue) {
 			continue
 		}
 
-		value, err := getValue(originValue, field)
+		value, err := field.encode(fieldValue)
 		if err != nil {
-			return nil, err
+			return nil, nil, err
+		}
+
+		// 按配置处理 nil
+		if value == nil {
+			switch e.opts.NilPolicy {
+			case NilSkip:
+				continue
+			case NilDelete:
+				nils = append(nils, field.name)
+				continue
+			case NilMarker:
+				value = e.opts.NullMarker
+			}
 		}
 
-		result[tag.Name] = value
+		result[field.name] = value
 	}
-	return result, nil
+	return result, nils, nil
 }
 
 // ----------------------------------------
-// 根据字段类型，转换成可用类型
+// 根据字段类型，选择转换成可用类型的方法
 // ----------------------------------------
-func getValue(originValue reflect.Value, field reflect.StructField) (interface{}, error) {
-	fieldValue := originValue.FieldByName(field.Name)
+func (e *engine) encoderFor(field reflect.StructField, tag *FieldTag) encodeFunc {
+	return e.typeEncoder(field.Name, field.Type, tag)
+}
 
+func (e *engine) typeEncoder(name string, t reflect.Type, tag *FieldTag) encodeFunc {
+	// 注册了转换器，指针类型也可以注册
+	if encoder := e.registry.encoder(t); encoder != nil {
+		return encoder
+	}
 	// 指针有专门的处理
-	if field.Type.Kind() == reflect.Ptr {
-		return getPtrValue(originValue, field)
+	if t.Kind() == reflect.Ptr {
+		return ptrEncoder(e.typeEncoder(name, t.Elem(), tag))
+	}
+	// 自定义了存储格式
+	if encoder := marshalerEncoder(t); encoder != nil {
+		return encoder
 	}
 
-	switch field.Type.Kind() {
+	switch t.Kind() {
 	// 处理所有 Int 类型
 	case reflect.Int64, reflect.Int32, reflect.Int16, reflect.Int8, reflect.Int:
-		return fieldValue.Int(), nil
+		return getIntValue
 	// 处理所有 Uint 类型
 	case reflect.Uint64, reflect.Uint32, reflect.Uint16, reflect.Uint8, reflect.Uint:
-		return fieldValue.Uint(), nil
+		return getUintValue
 	// 处理字符串类型
 	case reflect.String:
-		return fieldValue.Interface(), nil
+		return getInterfaceValue
 	// 处理布尔类型
 	case reflect.Bool:
-		return fieldValue.Bool(), nil
+		return getBoolValue
 	// 处理浮点类型
 	case reflect.Float64, reflect.Float32:
-		return fieldValue.Float(), nil
+		return getFloatValue
 	// 处理切片类型
 	case reflect.Slice:
-		return getJsonValue(fieldValue)
+		return e.nestedEncoder(t, tag)
 	// 处理结构体类型
 	case reflect.Struct, reflect.Map:
-		if field.Type.String() == "time.Time" {
-			return getTimeValue(fieldValue)
+		if t == timeType {
+			return e.timeEncoder(tag)
 		}
-		return getJsonValue(fieldValue)
+		return e.nestedEncoder(t, tag)
 	// 处理 interface 类型
 	case reflect.Interface:
-		return fieldValue.Interface(), nil
+		return getInterfaceValue
 	default:
-		errMsg := fmt.Sprintf("unsupported type name=%s type=%s", field.Name, field.Type)
-		return nil, errors.New(errMsg)
+		errMsg := fmt.Sprintf("unsupported type name=%s type=%s", name, t)
+		return func(reflect.Value) (interface{}, error) {
+			return nil, errors.New(errMsg)
+		}
 	}
 }
 
-func getTimeValue(fieldValue reflect.Value) (interface{}, error) {
-	t := fieldValue.Interface().(time.Time)
-	value := t.Local().Format("2006-01-02 15:04:05")
-	return value, nil
+// ptrEncoder 指针为 nil 时返回 nil，否则按指向的类型转换
+func ptrEncoder(elemEncoder encodeFunc) encodeFunc {
+	return func(fieldValue reflect.Value) (interface{}, error) {
+		if fieldValue.IsNil() {
+			return nil, nil
+		}
+		return elemEncoder(fieldValue.Elem())
+	}
 }
 
-func getJsonValue(fieldValue reflect.Value) (interface{}, error) {
-	return json.Marshal(fieldValue.Interface())
+// isEmptyValue 判断是否为零值，规则同 encoding/json，另外零值时间也算空
+func isEmptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool:
+		return !v.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int() == 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return v.Uint() == 0
+	case reflect.Float32, reflect.Float64:
+		return v.Float() == 0
+	case reflect.Interface, reflect.Ptr:
+		return v.IsNil()
+	case reflect.Struct:
+		if v.Type() == timeType {
+			return v.Interface().(time.Time).IsZero()
+		}
+	}
+	return false
 }
 
-// ----------------------------------------
-// 根据字段类型，转换成可用类型, 专供指针
-// ----------------------------------------
-func getPtrValue(originValue reflect.Value, field reflect.StructField) (interface{}, error) {
+func getIntValue(fieldValue reflect.Value) (interface{}, error) {
+	return fieldValue.Int(), nil
+}
 
-	fieldValue := originValue.FieldByName(field.Name)
+func getUintValue(fieldValue reflect.Value) (interface{}, error) {
+	return fieldValue.Uint(), nil
+}
 
-	if fieldValue.IsNil() {
-		return nil, nil
-	}
+func getBoolValue(fieldValue reflect.Value) (interface{}, error) {
+	return fieldValue.Bool(), nil
+}
 
-	switch fieldValue.Type().Elem().Kind() {
-	// 处理所有 Int 指针类型
-	case reflect.Int64, reflect.Int32, reflect.Int16, reflect.Int8, reflect.Int:
-		return fieldValue.Elem().Interface(), nil
-	// 处理所有 Uint 指针类型
-	case reflect.Uint64, reflect.Uint32, reflect.Uint16, reflect.Uint8, reflect.Uint:
-		return fieldValue.Elem().Interface(), nil
-	// 处理字符串指针, 布尔指针类型
-	case reflect.String, reflect.Bool:
-		return fieldValue.Elem().Interface(), nil
-	// 处理布尔指针类型
-	case reflect.Float64, reflect.Float32:
-		return fieldValue.Elem().Interface(), nil
-	// 处理自定义结构体指针
-	default:
-		if fieldValue.Type().Elem().String() == "time.Time" {
-			return getPtrTimeValue(fieldValue)
-		}
-		return getPtrJsonValue(fieldValue)
-	}
+func getFloatValue(fieldValue reflect.Value) (interface{}, error) {
+	return fieldValue.Float(), nil
 }
 
-func getPtrTimeValue(fieldValue reflect.Value) (interface{}, error) {
-	t := fieldValue.Elem().Interface().(time.Time)
-	value := t.Local().Format("2006-01-02 15:04:05")
-	return value, nil
+func getInterfaceValue(fieldValue reflect.Value) (interface{}, error) {
+	return fieldValue.Interface(), nil
 }
 
-func getPtrJsonValue(fieldValue reflect.Value) (interface{}, error) {
-	return json.Marshal(fieldValue.Elem().Interface())
+// nestedEncoder 嵌套值按字段的编解码转换，protobuf 的消息需要传入指针
+func (e *engine) nestedEncoder(t reflect.Type, tag *FieldTag) encodeFunc {
+	codec, err := e.nestedCodec(t, tag)
+	if err != nil {
+		return func(reflect.Value) (interface{}, error) {
+			return nil, err
+		}
+	}
+	if isProtoMessage(t) {
+		return func(fieldValue reflect.Value) (interface{}, error) {
+			if !fieldValue.CanAddr() {
+				ptr := reflect.New(t)
+				ptr.Elem().Set(fieldValue)
+				return codec.Marshal(ptr.Interface())
+			}
+			return codec.Marshal(fieldValue.Addr().Interface())
+		}
+	}
+	return func(fieldValue reflect.Value) (interface{}, error) {
+		return codec.Marshal(fieldValue.Interface())
+	}
 }
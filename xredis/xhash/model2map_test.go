@@ -0,0 +1,85 @@
+package xhash
+
+import (
+	"github.com/stretchr/testify/suite"
+	"testing"
+	"time"
+)
+
+type Model2mapTestSuite struct {
+	suite.Suite
+}
+
+func (s *Model2mapTestSuite) SetupTest() {}
+
+// 测试基础类型
+func (s *Model2mapTestSuite) TestBasic() {
+	number := 10
+	type model struct {
+		Id      int64
+		Age     uint8
+		Name    string
+		IsNew   bool
+		Score   float64
+		Number  *int
+		Nothing *int
+		Ignore  string `redis:"-"`
+	}
+	result, err := Model2map(&model{Id: 1, Age: 2, Name: "william", IsNew: true, Score: 3.5, Number: &number})
+	s.Nil(err)
+	s.Equal(result["id"], int64(1), "test int value err")
+	s.Equal(result["age"], uint64(2), "test uint value err")
+	s.Equal(result["name"], "william", "test string value err")
+	s.Equal(result["is_new"], true, "test bool value err")
+	s.Equal(result["score"], 3.5, "test float value err")
+	s.Equal(result["number"], int64(10), "test ptr value err")
+	s.Nil(result["nothing"], "test nil ptr value err")
+	s.NotContains(result, "ignore", "test ignore err")
+}
+
+// 测试 json 与时间
+func (s *Model2mapTestSuite) TestJsonAndTime() {
+	type model struct {
+		Tags      []string
+		CreatedAt time.Time
+	}
+	createdAt := time.Date(2019, 5, 23, 10, 20, 30, 0, time.Local)
+	result, err := Model2map(&model{Tags: []string{"man"}, CreatedAt: createdAt})
+	s.Nil(err)
+	s.Equal(string(result["tags"].([]byte)), `["man"]`, "test slice value err")
+	s.Equal(result["created_at"], "2019-05-23 10:20:30", "test time value err")
+}
+
+// 测试零值不存储
+func (s *Model2mapTestSuite) TestOmitEmpty() {
+	type model struct {
+		Id        int64     `redis:";omitempty"`
+		Name      string    `redis:";omitempty"`
+		Tags      []string  `redis:";omitempty"`
+		Score     *float64  `redis:";omitempty"`
+		CreatedAt time.Time `redis:";omitempty"`
+		IsNew     bool
+	}
+	result, err := Model2map(&model{})
+	s.Nil(err)
+	s.Equal(len(result), 1, "test omitempty len err")
+	s.Contains(result, "is_new", "test omitempty err")
+
+	result, err = Model2map(&model{Id: 1, Name: "william"})
+	s.Nil(err)
+	s.Equal(len(result), 3, "test omitempty len err")
+}
+
+// 测试不支持的类型
+func (s *Model2mapTestSuite) TestNotSupportType() {
+	type model struct {
+		Value complex128
+	}
+	_, err := Model2map(&model{})
+	s.NotEmpty(err)
+	s.Contains(err.Error(), "unsupported type", "test value err")
+}
+
+func TestModel2mapSuite(t *testing.T) {
+	suite.Run(t, new(Model2mapTestSuite))
+}
@@ -0,0 +1,120 @@
+package xhash
+
+import (
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// 内置命名规则的名称，用于模型级别的 naming 选项，例: `redis:"naming=snake"`
+const (
+	// NamingLegacy 每个大写字母前加下划线，即 Hump2underline，默认规则，兼容已有数据
+	NamingLegacy = "legacy"
+
+	// NamingSnake 识别缩写的下划线命名，例: UserID -> user_id, HTTPServer -> http_server
+	NamingSnake = "snake"
+
+	// NamingCamel 小驼峰，例: UserID -> userId
+	NamingCamel = "camel"
+
+	// NamingKebab 识别缩写的中划线命名，例: UserID -> user-id
+	NamingKebab = "kebab"
+
+	// NamingGo 与 Go 字段名相同
+	NamingGo = "go"
+
+	// NamingLower 全部小写，例: UserID -> userid
+	NamingLower = "lower"
+)
+
+var (
+	namingMu sync.RWMutex
+	namings  = map[string]NamingStrategy{
+		NamingLegacy: Hump2underline,
+		NamingSnake:  SnakeCase,
+		NamingCamel:  CamelCase,
+		NamingKebab:  KebabCase,
+		NamingGo:     GoName,
+		NamingLower:  LowerCase,
+	}
+)
+
+// RegisterNaming 注册自定义的命名规则，之后可以在模型级别的 naming 选项中使用，应在初始化时注册
+func RegisterNaming(name string, naming NamingStrategy) {
+	namingMu.Lock()
+	defer namingMu.Unlock()
+	namings[name] = naming
+}
+
+// LookupNaming 按名称查找命名规则
+func LookupNaming(name string) (NamingStrategy, bool) {
+	namingMu.RLock()
+	defer namingMu.RUnlock()
+	naming, ok := namings[name]
+	return naming, ok
+}
+
+// SnakeCase 识别缩写的下划线命名，连续的大写字母视为一个单词
+func SnakeCase(name string) string {
+	return strings.ToLower(strings.Join(splitWords(name), "_"))
+}
+
+// KebabCase 识别缩写的中划线命名
+func KebabCase(name string) string {
+	return strings.ToLower(strings.Join(splitWords(name), "-"))
+}
+
+// CamelCase 小驼峰，缩写也按普通单词处理，例: HTTPServer -> httpServer
+func CamelCase(name string) string {
+	var builder strings.Builder
+	for i, word := range splitWords(name) {
+		word = strings.ToLower(word)
+		if i > 0 {
+			runes := []rune(word)
+			runes[0] = unicode.ToUpper(runes[0])
+			word = string(runes)
+		}
+		builder.WriteString(word)
+	}
+	return builder.String()
+}
+
+// GoName 与 Go 字段名相同
+func GoName(name string) string {
+	return name
+}
+
+// LowerCase 全部小写
+func LowerCase(name string) string {
+	return strings.ToLower(name)
+}
+
+// splitWords 按大小写及 _ - 拆分单词，连续的大写字母为一个单词，最后一个大写字母后跟小写时属于下一个单词
+// 例: HTTPServer -> HTTP Server, UserID -> User ID, Md5Sum -> Md5 Sum
+func splitWords(name string) []string {
+	runes := []rune(name)
+	var words []string
+	start := 0
+	for i, r := range runes {
+		if r == '_' || r == '-' {
+			if i > start {
+				words = append(words, string(runes[start:i]))
+			}
+			start = i + 1
+			continue
+		}
+		if i == start || !unicode.IsUpper(r) {
+			continue
+		}
+		prev := runes[i-1]
+		nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+		if unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && nextLower) {
+			words = append(words, string(runes[start:i]))
+			start = i
+		}
+	}
+	if start < len(runes) {
+		words = append(words, string(runes[start:]))
+	}
+	return words
+}
@@ -0,0 +1,113 @@
+package xhash
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestNamingStrategies(t *testing.T) {
+	cases := []struct {
+		name                               string
+		legacy, snake, camel, kebab, lower string
+	}{
+		{"Id", "id", "id", "id", "id", "id"},
+		{"UserID", "user_i_d", "user_id", "userId", "user-id", "userid"},
+		{"HTTPServer", "h_t_t_p_server", "http_server", "httpServer", "http-server", "httpserver"},
+		{"RedPacketId", "red_packet_id", "red_packet_id", "redPacketId", "red-packet-id", "redpacketid"},
+		{"Md5Sum", "md5_sum", "md5_sum", "md5Sum", "md5-sum", "md5sum"},
+		{"Created_At", "created__at", "created_at", "createdAt", "created-at", "created_at"},
+	}
+	for _, c := range cases {
+		if got := Hump2underline(c.name); got != c.legacy {
+			t.Errorf("legacy name=%s got=%s", c.name, got)
+		}
+		if got := SnakeCase(c.name); got != c.snake {
+			t.Errorf("snake name=%s got=%s", c.name, got)
+		}
+		if got := CamelCase(c.name); got != c.camel {
+			t.Errorf("camel name=%s got=%s", c.name, got)
+		}
+		if got := KebabCase(c.name); got != c.kebab {
+			t.Errorf("kebab name=%s got=%s", c.name, got)
+		}
+		if got := LowerCase(c.name); got != c.lower {
+			t.Errorf("lower name=%s got=%s", c.name, got)
+		}
+		if got := GoName(c.name); got != c.name {
+			t.Errorf("go name=%s got=%s", c.name, got)
+		}
+	}
+}
+
+type namingInner struct {
+	_        struct{} `redis:"naming=go"`
+	InnerID  int64
+	Nickname string `redis:"nick"`
+}
+
+type namingUser struct {
+	_      struct{} `redis:"naming=snake"`
+	UserID int64
+	namingInner
+	Info struct {
+		HTTPPort int
+	} `redis:";inline"`
+}
+
+type namingUnknown struct {
+	_    struct{} `redis:"naming=unknown"`
+	Name string
+}
+
+func TestStructNaming(t *testing.T) {
+	result, err := Model2map(&namingUser{UserID: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"user_id", "InnerID", "nick", "http_port"} {
+		if _, ok := result[name]; !ok {
+			t.Errorf("struct naming err name=%s result=%v", name, result)
+		}
+	}
+
+	// 模型级别的规则优先于配置
+	result, err = NewEncoder(Options{Naming: strings.ToUpper}).Encode(&namingUser{})
+	if _, ok := result["user_id"]; err != nil || !ok {
+		t.Errorf("struct naming should override options result=%v err=%v", result, err)
+	}
+	result, err = NewEncoder(Options{Naming: CamelCase}).Encode(&keyUser{})
+	if err != nil || len(result) == 0 {
+		t.Fatalf("options naming err result=%v err=%v", result, err)
+	}
+	for name := range result {
+		if strings.Contains(name, "_") {
+			t.Errorf("options naming err name=%s", name)
+		}
+	}
+
+	RegisterNaming("upper", strings.ToUpper)
+	type namingCustom struct {
+		_      struct{} `redis:"naming=upper"`
+		UserID int64
+	}
+	result, err = Model2map(&namingCustom{UserID: 1})
+	if _, ok := result["USERID"]; err != nil || !ok {
+		t.Errorf("custom naming err result=%v err=%v", result, err)
+	}
+
+	if _, err := Model2map(&namingUnknown{}); err == nil {
+		t.Errorf("unknown naming should fail")
+	}
+	if err := Map2model(map[string]string{}, &namingUnknown{}); err == nil {
+		t.Errorf("unknown naming should fail")
+	}
+	if _, err := DescribeStruct(reflect.TypeOf(namingUnknown{})); err == nil {
+		t.Errorf("unknown naming should fail")
+	}
+
+	tag := ParseTagWithNaming(reflect.TypeOf(namingUser{}).Field(1), SnakeCase)
+	if tag.Name != "user_id" {
+		t.Errorf("parse tag with naming err name=%s", tag.Name)
+	}
+}
@@ -0,0 +1,82 @@
+package xhash
+
+import (
+	"testing"
+)
+
+type nilModel struct {
+	Id       int64
+	Nickname *string
+	Score    *int
+	Value    interface{}
+}
+
+func TestNilPolicy(t *testing.T) {
+	origin := &nilModel{Id: 1}
+
+	// 默认保持原有行为，map 中为 nil
+	data, err := NewEncoder(Options{}).Encode(origin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if value, ok := data["nickname"]; !ok || value != nil {
+		t.Errorf("nil as empty err data=%v", data)
+	}
+
+	data, err = NewEncoder(Options{NilPolicy: NilSkip}).Encode(origin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(data) != 1 {
+		t.Errorf("nil skip err data=%v", data)
+	}
+
+	changes, err := NewEncoder(Options{NilPolicy: NilDelete}).EncodeChanges(origin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changes.Set) != 1 || len(changes.Del) != 3 || changes.Del[0] != "nickname" {
+		t.Errorf("nil delete err changes=%+v", changes)
+	}
+
+	// 只有 NilDelete 时 Del 不为空
+	changes, err = NewEncoder(Options{}).EncodeChanges(origin, "Score")
+	if err != nil || len(changes.Set) != 1 || len(changes.Del) != 0 {
+		t.Errorf("nil as empty changes err changes=%+v err=%v", changes, err)
+	}
+}
+
+func TestNilMarker(t *testing.T) {
+	opts := Options{NilPolicy: NilMarker}
+	data, err := NewEncoder(opts).Encode(&nilModel{Id: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data["nickname"] != DefaultNullMarker || data["score"] != DefaultNullMarker {
+		t.Errorf("nil marker encode err data=%v", data)
+	}
+
+	// 标记还原为 nil，原有的值被清空
+	nickname := "will"
+	result := &nilModel{Nickname: &nickname}
+	hash := map[string]string{"id": "1", "nickname": DefaultNullMarker, "score": "10", "value": DefaultNullMarker}
+	if err := NewDecoder(opts).Decode(hash, result); err != nil {
+		t.Fatal(err)
+	}
+	if result.Nickname != nil || *result.Score != 10 || result.Value != nil {
+		t.Errorf("nil marker decode err result=%+v", result)
+	}
+
+	// 自定义标记，非指针字段不受影响
+	type model struct {
+		Name string
+	}
+	opts.NullMarker = "NULL"
+	plain := new(model)
+	if err := NewDecoder(opts).Decode(map[string]string{"name": "NULL"}, plain); err != nil {
+		t.Fatal(err)
+	}
+	if plain.Name != "NULL" {
+		t.Errorf("nil marker should not touch non nilable field result=%+v", plain)
+	}
+}
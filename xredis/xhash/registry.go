@@ -0,0 +1,116 @@
+package xhash
+
+import (
+	"fmt"
+	"github.com/pkg/errors"
+	"reflect"
+	"sync"
+	"sync/atomic"
+)
+
+// EncodeFunc 将值转成存储到 hash 中的字符串，value 的类型为注册时的类型
+type EncodeFunc func(value interface{}) (string, error)
+
+// DecodeFunc 将 hash 中的字符串解析成注册时的类型
+type DecodeFunc func(originVal string) (interface{}, error)
+
+// converter 注册的转换器
+type converter struct {
+	encode EncodeFunc
+	decode DecodeFunc
+}
+
+// Registry 转换器注册表，用于无法添加方法的第三方类型，例: decimal.Decimal, uuid.UUID
+// 转换时优先使用注册的转换器，其次才是 Marshaler 及内置的类型处理
+type Registry struct {
+	mu         sync.RWMutex
+	converters map[reflect.Type]converter
+	version    uint64 // 每次注册加一，用于让已缓存的结构体信息失效
+	engine     *engine
+}
+
+// DefaultRegistry 包级别的 Map2model / Model2map 及未指定注册表的 Encoder / Decoder 使用的注册表
+var DefaultRegistry *Registry
+
+func init() {
+	DefaultRegistry = NewRegistry()
+	defaultEncoder = &Encoder{engine: DefaultRegistry.engine}
+	defaultDecoder = &Decoder{engine: DefaultRegistry.engine}
+}
+
+// NewRegistry 创建一个空的注册表，不同的服务可以各自注册不同的规则
+func NewRegistry() *Registry {
+	r := &Registry{converters: make(map[reflect.Type]converter)}
+	r.engine = newEngine(Options{Registry: r})
+	return r
+}
+
+// Register 注册类型的转换器，encode 或 decode 为 nil 时该方向使用默认的处理
+// 例: r.Register(reflect.TypeOf(decimal.Decimal{}), encode, decode)
+func (r *Registry) Register(t reflect.Type, encode EncodeFunc, decode DecodeFunc) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.converters[t] = converter{encode: encode, decode: decode}
+	atomic.AddUint64(&r.version, 1)
+}
+
+// Map2model 使用该注册表及默认配置将 map 转模型
+func (r *Registry) Map2model(origin map[string]string, target interface{}) error {
+	return r.engine.map2model(origin, target)
+}
+
+// Model2map 使用该注册表及默认配置将模型转 map
+func (r *Registry) Model2map(origin interface{}) (map[string]interface{}, error) {
+	result, _, err := r.engine.model2map(origin)
+	return result, err
+}
+
+func (r *Registry) currentVersion() uint64 {
+	return atomic.LoadUint64(&r.version)
+}
+
+func (r *Registry) lookup(t reflect.Type) (converter, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	c, ok := r.converters[t]
+	return c, ok
+}
+
+// encoder 注册了 encode 时返回对应的处理，指针为 nil 时不调用
+func (r *Registry) encoder(t reflect.Type) encodeFunc {
+	c, ok := r.lookup(t)
+	if !ok || c.encode == nil {
+		return nil
+	}
+	return func(fieldValue reflect.Value) (interface{}, error) {
+		if fieldValue.Kind() == reflect.Ptr && fieldValue.IsNil() {
+			return nil, nil
+		}
+		return c.encode(fieldValue.Interface())
+	}
+}
+
+// decoder 注册了 decode 时返回对应的处理，解析结果的类型需要与注册的类型一致
+func (r *Registry) decoder(t reflect.Type) decodeFunc {
+	c, ok := r.lookup(t)
+	if !ok || c.decode == nil {
+		return nil
+	}
+	return func(fieldValue reflect.Value, originVal string) error {
+		result, err := c.decode(originVal)
+		if err != nil {
+			return err
+		}
+		value := reflect.ValueOf(result)
+		if !value.IsValid() {
+			fieldValue.Set(reflect.Zero(t))
+			return nil
+		}
+		if !value.Type().AssignableTo(t) {
+			errMsg := fmt.Sprintf("converter returned %s, expected %s", value.Type(), t)
+			return errors.New(errMsg)
+		}
+		fieldValue.Set(value)
+		return nil
+	}
+}
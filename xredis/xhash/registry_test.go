@@ -0,0 +1,129 @@
+package xhash
+
+import (
+	"database/sql"
+	"math/big"
+	"reflect"
+	"testing"
+)
+
+func newTestRegistry() *Registry {
+	r := NewRegistry()
+	r.Register(reflect.TypeOf(sql.NullString{}),
+		func(value interface{}) (string, error) {
+			return value.(sql.NullString).String, nil
+		},
+		func(originVal string) (interface{}, error) {
+			return sql.NullString{String: originVal, Valid: true}, nil
+		})
+	r.Register(reflect.TypeOf(&big.Int{}),
+		func(value interface{}) (string, error) {
+			return value.(*big.Int).String(), nil
+		},
+		func(originVal string) (interface{}, error) {
+			i, ok := new(big.Int).SetString(originVal, 10)
+			if !ok {
+				return nil, sql.ErrNoRows
+			}
+			return i, nil
+		})
+	return r
+}
+
+func TestRegistry(t *testing.T) {
+	type model struct {
+		Name    sql.NullString
+		Balance *big.Int
+		Nothing *big.Int
+	}
+
+	r := newTestRegistry()
+	balance, _ := new(big.Int).SetString("123456789012345678901234567890", 10)
+	data, err := r.Model2map(&model{Name: sql.NullString{String: "william", Valid: true}, Balance: balance})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data["name"] != "william" || data["balance"] != "123456789012345678901234567890" {
+		t.Errorf("registry encode err data=%v", data)
+	}
+	if data["nothing"] != nil {
+		t.Errorf("registry nil ptr err data=%v", data)
+	}
+
+	result := new(model)
+	err = r.Map2model(map[string]string{"name": "wade", "balance": "100"}, result)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.Name.Valid || result.Name.String != "wade" || result.Balance.Int64() != 100 {
+		t.Errorf("registry decode err result=%+v", result)
+	}
+
+	// 其他注册表不受影响，sql.NullString 仍然按 json 处理
+	data, err = Model2map(&model{Name: sql.NullString{String: "william", Valid: true}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := data["name"].([]byte); !ok {
+		t.Errorf("default registry should not use converter data=%v", data)
+	}
+}
+
+func TestRegistryReset(t *testing.T) {
+	type model struct {
+		Name sql.NullString
+	}
+
+	// 已经缓存过的类型，注册后需要重新分析
+	r := NewRegistry()
+	if _, err := r.Model2map(&model{}); err != nil {
+		t.Fatal(err)
+	}
+	r.Register(reflect.TypeOf(sql.NullString{}), func(value interface{}) (string, error) {
+		return "registered", nil
+	}, nil)
+	data, err := r.Model2map(&model{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data["name"] != "registered" {
+		t.Errorf("registry cache not reset data=%v", data)
+	}
+
+	// 只注册了 encode，decode 仍然按 json 处理
+	result := new(model)
+	if err := r.Map2model(map[string]string{"name": `{"String":"wade","Valid":true}`}, result); err != nil {
+		t.Fatal(err)
+	}
+	if result.Name.String != "wade" {
+		t.Errorf("registry decode fallback err result=%+v", result)
+	}
+}
+
+func TestRegistryTypeMismatch(t *testing.T) {
+	type model struct {
+		Name sql.NullString
+	}
+	r := NewRegistry()
+	r.Register(reflect.TypeOf(sql.NullString{}), nil, func(originVal string) (interface{}, error) {
+		return originVal, nil
+	})
+	err := r.Map2model(map[string]string{"name": "wade"}, new(model))
+	if err == nil {
+		t.Error("registry type mismatch should return err")
+	}
+}
+
+func TestRegistryOption(t *testing.T) {
+	type model struct {
+		Balance *big.Int
+	}
+	enc := NewEncoder(Options{Registry: newTestRegistry()})
+	data, err := enc.Encode(&model{Balance: big.NewInt(100)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data["balance"] != "100" {
+		t.Errorf("registry option err data=%v", data)
+	}
+}
@@ -0,0 +1,83 @@
+package xhash
+
+import "sort"
+
+// Snapshot 模型转换后在 hash 中的状态，用于计算哪些字段发生了变化
+// 值为 nil 的字段不在 Snapshot 中
+type Snapshot map[string]string
+
+// Changes 与 Snapshot 相比发生变化的字段
+type Changes struct {
+	Set map[string]interface{} // 新增或修改的字段，用 HSET 写入
+	Del []string               // 变为 nil 或不再存储的字段，用 HDEL 删除
+}
+
+// Empty 是否没有任何变化
+func (c *Changes) Empty() bool {
+	return len(c.Set) == 0 && len(c.Del) == 0
+}
+
+// Apply 将变化应用到 Snapshot，保存成功后调用，之后可以继续用于下一次比较
+func (s Snapshot) Apply(changes *Changes) {
+	for key, value := range changes.Set {
+		s[key] = formatValue(value)
+	}
+	for _, key := range changes.Del {
+		delete(s, key)
+	}
+}
+
+// TakeSnapshot 记录模型当前的状态，使用默认配置
+func TakeSnapshot(model interface{}) (Snapshot, error) {
+	return defaultEncoder.Snapshot(model)
+}
+
+// Diff 模型与 Snapshot 相比发生变化的字段，使用默认配置
+func Diff(snapshot Snapshot, model interface{}) (*Changes, error) {
+	return defaultEncoder.Diff(snapshot, model)
+}
+
+// Snapshot 记录模型当前的状态，通常在读取之后调用
+func (enc *Encoder) Snapshot(model interface{}) (Snapshot, error) {
+	fields, err := enc.Encode(model)
+	if err != nil {
+		return nil, err
+	}
+	snapshot := make(Snapshot, len(fields))
+	for key, value := range fields {
+		if value != nil {
+			snapshot[key] = formatValue(value)
+		}
+	}
+	return snapshot, nil
+}
+
+// Diff 模型与 Snapshot 相比发生变化的字段，按写入 hash 的文本比较
+func (enc *Encoder) Diff(snapshot Snapshot, model interface{}) (*Changes, error) {
+	fields, err := enc.Encode(model)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := &Changes{Set: make(map[string]interface{})}
+	for key, value := range fields {
+		old, has := snapshot[key]
+		if value == nil {
+			if has {
+				changes.Del = append(changes.Del, key)
+			}
+			continue
+		}
+		if !has || old != formatValue(value) {
+			changes.Set[key] = value
+		}
+	}
+	// omitempty 的零值或内嵌的 nil 指针，不再存储的字段也需要删除
+	for key := range snapshot {
+		if _, ok := fields[key]; !ok {
+			changes.Del = append(changes.Del, key)
+		}
+	}
+	sort.Strings(changes.Del)
+	return changes, nil
+}
@@ -0,0 +1,61 @@
+package xhash
+
+import (
+	"testing"
+)
+
+func TestSnapshotDiff(t *testing.T) {
+	type model struct {
+		Id       int64
+		Name     string
+		Score    float64
+		Nickname *string
+		Tags     []string `redis:";omitempty"`
+	}
+
+	nickname := "will"
+	user := &model{Id: 1, Name: "william", Score: 3.5, Nickname: &nickname, Tags: []string{"man"}}
+	snapshot, err := TakeSnapshot(user)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if snapshot["score"] != "3.5" || snapshot["tags"] != `["man"]` || snapshot["nickname"] != "will" {
+		t.Errorf("snapshot value err snapshot=%v", snapshot)
+	}
+
+	// 没有变化
+	changes, err := Diff(snapshot, user)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !changes.Empty() {
+		t.Errorf("no changes expected changes=%+v", changes)
+	}
+
+	// 修改、变为 nil、omitempty 的零值
+	user.Score = 4
+	user.Nickname = nil
+	user.Tags = nil
+	changes, err = Diff(snapshot, user)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changes.Set) != 1 || changes.Set["score"] != float64(4) {
+		t.Errorf("changes set err set=%v", changes.Set)
+	}
+	if len(changes.Del) != 2 || changes.Del[0] != "nickname" || changes.Del[1] != "tags" {
+		t.Errorf("changes del err del=%v", changes.Del)
+	}
+
+	// 应用后再比较没有变化，nil 又有了值需要写入
+	snapshot.Apply(changes)
+	changes, err = Diff(snapshot, user)
+	if err != nil || !changes.Empty() {
+		t.Errorf("no changes expected after apply changes=%+v err=%v", changes, err)
+	}
+	user.Nickname = &nickname
+	changes, err = Diff(snapshot, user)
+	if err != nil || len(changes.Set) != 1 || changes.Set["nickname"] != "will" {
+		t.Errorf("nickname should be set changes=%+v err=%v", changes, err)
+	}
+}
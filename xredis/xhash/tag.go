@@ -13,22 +13,120 @@ const (
 
 	// XHashTagSep tag 的分隔符
 	XHashTagSep = ";"
+
+	// TagOmitEmpty 零值不存储
+	TagOmitEmpty = "omitempty"
+
+	// TagRequired hash 中必须包含该字段
+	TagRequired = "required"
+
+	// TagDefault hash 中没有该字段时使用的默认值，例: default=1
+	TagDefault = "default"
+
+	// TagInline 将结构体字段展开到上一层，内嵌结构体默认展开
+	TagInline = "inline"
+
+	// TagTime 时间的存储格式，可以是 layout 或 TimeFormatUnix 等，例: time=unixmilli
+	TagTime = "time"
+
+	// TagLocation 时间的时区，例: loc=Asia/Shanghai
+	TagLocation = "loc"
+
+	// TagVersion 乐观锁的版本字段，只能是整数，写入成功后加一
+	TagVersion = "version"
+
+	// TagTTL 过期时间，格式同 time.ParseDuration，例: ttl=30m
+	// 写在 _ 字段上为整个 hash 的过期时间，写在字段上为 hash 字段的过期时间（需要 Redis 7.4 及以上）
+	TagTTL = "ttl"
+
+	// TagCodec 切片、map、结构体的编解码，可选 json msgpack gob proto 或 RegisterCodec 注册的名称，例: codec=msgpack
+	TagCodec = "codec"
+
+	// TagKeyPattern 模型级别的选项，key 的模板，例: key=order:{{shop_id}}:{id}
+	TagKeyPattern = "key"
+
+	// TagNaming 模型级别的选项，该结构体中字段的命名规则，例: naming=snake，见 LookupNaming
+	TagNaming = "naming"
 )
 
 // FieldTag 分析后的 tag 数据结构
 type FieldTag struct {
-	Name     string // 字段存储的名称
-	IsIgnore bool   // 是否忽略该字段，不存储
+	Name       string // 字段存储的名称
+	IsIgnore   bool   // 是否忽略该字段，不存储
+	OmitEmpty  bool   // 零值是否不存储
+	Required   bool   // 读取时 hash 中是否必须包含该字段
+	Default    string // 读取时 hash 中没有该字段使用的默认值
+	HasDefault bool   // 是否设置了默认值
+	Inline     bool   // 结构体字段是否展开到上一层
+	TimeFormat string // 时间的存储格式，为空时使用配置
+	Location   string // 时间的时区，为空时使用配置
+	Version    bool   // 是否为乐观锁的版本字段
+	TTL        string // hash 字段的过期时间，为空时不过期
+	Codec      string // 嵌套值的编解码名称，为空时使用配置
+}
+
+// ModelTag 模型级别的选项，写在名为 _ 的字段上，例:
+//
+//	type User struct {
+//		_  struct{} `redis:"key=user:{id}"`
+//		Id int64
+//	}
+type ModelTag struct {
+	Key    string // key 的模板，{name} 替换为 hash 中 name 字段的值
+	TTL    string // 整个 hash 的过期时间，为空时不过期
+	Naming string // 字段的命名规则名称，为空时使用配置
+}
+
+// ParseModelTag 分析模型级别的选项，使用默认的 tag 名称
+func ParseModelTag(t reflect.Type) *ModelTag {
+	return parseModelTag(t, XHashTag)
+}
+
+// parseModelTag 按指定的 tag 名称分析模型级别的选项，多个 _ 字段的选项会合并
+func parseModelTag(t reflect.Type, tagKey string) *ModelTag {
+	modelTag := &ModelTag{}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.Name != "_" {
+			continue
+		}
+		tagStr := field.Tag.Get(tagKey)
+		if tagStr == "" {
+			continue
+		}
+		for _, option := range strings.Split(tagStr, XHashTagSep) {
+			key, value := splitOption(option)
+			switch key {
+			case TagKeyPattern:
+				modelTag.Key = value
+			case TagTTL:
+				modelTag.TTL = value
+			case TagNaming:
+				modelTag.Naming = value
+			}
+		}
+	}
+	return modelTag
 }
 
-// ParseTag 分析字段的 tag
+// ParseTag 分析字段的 tag，使用默认的 tag 名称与命名规则
 func ParseTag(field reflect.StructField) *FieldTag {
+	return parseTag(field, XHashTag, Hump2underline)
+}
+
+// ParseTagWithNaming 分析字段的 tag，tag 中未指定名称时使用 naming
+func ParseTagWithNaming(field reflect.StructField, naming NamingStrategy) *FieldTag {
+	return parseTag(field, XHashTag, naming)
+}
+
+// parseTag 按指定的 tag 名称与命名规则分析字段的 tag
+func parseTag(field reflect.StructField, tagKey string, naming NamingStrategy) *FieldTag {
 	fieldTag := &FieldTag{
-		Name:     Hump2underline(field.Name),
+		Name:     naming(field.Name),
 		IsIgnore: false,
 	}
 
-	tagStr := field.Tag.Get(XHashTag)
+	tagStr := field.Tag.Get(tagKey)
 	tagGroup := strings.Split(tagStr, XHashTagSep)
 
 	// split 默认会有一个，如果为空，直接按默认返回
@@ -42,12 +140,50 @@ func ParseTag(field reflect.StructField) *FieldTag {
 		return fieldTag
 	}
 
-	// 自定义命名
-	fieldTag.Name = tagGroup[0]
+	// 自定义命名，为空时保留默认命名，例: `redis:";omitempty"`
+	if tagGroup[0] != "" {
+		fieldTag.Name = tagGroup[0]
+	}
+
+	// 其余为选项
+	for _, option := range tagGroup[1:] {
+		key, value := splitOption(option)
+		switch key {
+		case TagOmitEmpty:
+			fieldTag.OmitEmpty = true
+		case TagRequired:
+			fieldTag.Required = true
+		case TagDefault:
+			fieldTag.Default = value
+			fieldTag.HasDefault = true
+		case TagInline:
+			fieldTag.Inline = true
+		case TagTime:
+			fieldTag.TimeFormat = value
+		case TagLocation:
+			fieldTag.Location = value
+		case TagVersion:
+			fieldTag.Version = true
+		case TagTTL:
+			fieldTag.TTL = value
+		case TagCodec:
+			fieldTag.Codec = value
+		}
+	}
 	return fieldTag
 }
 
-// Hump2underline 将驼峰转为下划线
+// splitOption 将 key=value 形式的选项拆开，没有 = 时 value 为空
+func splitOption(option string) (string, string) {
+	option = strings.TrimSpace(option)
+	if i := strings.Index(option, "="); i >= 0 {
+		return option[:i], option[i+1:]
+	}
+	return option, ""
+}
+
+// Hump2underline 将驼峰转为下划线，每个大写字母前都加下划线，例: UserID -> user_i_d
+// 为兼容已有数据仍为默认规则，新的模型建议使用 SnakeCase
 func Hump2underline(name string) string {
 	buffer := bytes.NewBufferString("")
 	for i, r := range name {
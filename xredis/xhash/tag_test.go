@@ -1,6 +1,9 @@
 package xhash
 
-import "testing"
+import (
+	"reflect"
+	"testing"
+)
 
 func TestHump2underline(t *testing.T) {
 
@@ -16,3 +19,39 @@ func TestHump2underline(t *testing.T) {
 		t.Errorf("conver err name=%s result=%s", name, result)
 	}
 }
+
+func TestParseTag(t *testing.T) {
+	type model struct {
+		UserName string
+		Nickname string `redis:"nick"`
+		Ignore   string `redis:"-"`
+		Score    int    `redis:";omitempty;default=10"`
+		Status   int    `redis:"state;required"`
+	}
+	typ := reflect.TypeOf(model{})
+
+	tag := ParseTag(typ.Field(0))
+	if tag.Name != "user_name" || tag.IsIgnore {
+		t.Errorf("parse default tag err tag=%+v", tag)
+	}
+
+	tag = ParseTag(typ.Field(1))
+	if tag.Name != "nick" {
+		t.Errorf("parse name tag err tag=%+v", tag)
+	}
+
+	tag = ParseTag(typ.Field(2))
+	if !tag.IsIgnore {
+		t.Errorf("parse ignore tag err tag=%+v", tag)
+	}
+
+	tag = ParseTag(typ.Field(3))
+	if tag.Name != "score" || !tag.OmitEmpty || !tag.HasDefault || tag.Default != "10" {
+		t.Errorf("parse option tag err tag=%+v", tag)
+	}
+
+	tag = ParseTag(typ.Field(4))
+	if tag.Name != "state" || !tag.Required || tag.HasDefault {
+		t.Errorf("parse required tag err tag=%+v", tag)
+	}
+}
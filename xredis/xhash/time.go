@@ -0,0 +1,125 @@
+package xhash
+
+import (
+	"fmt"
+	"github.com/pkg/errors"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 除了 time.Format 的 layout，时间还支持以下存储格式
+const (
+	// TimeFormatUnix 秒级时间戳，存储为整数
+	TimeFormatUnix = "unix"
+
+	// TimeFormatUnixMilli 毫秒级时间戳，存储为整数
+	TimeFormatUnixMilli = "unixmilli"
+
+	// TimeFormatUnixNano 纳秒级时间戳，存储为整数，只支持 1678 年至 2262 年
+	TimeFormatUnixNano = "unixnano"
+
+	// TimeFormatRFC3339Nano time.RFC3339Nano 格式，保留纳秒与时区偏移，不做时区转换
+	TimeFormatRFC3339Nano = "rfc3339nano"
+)
+
+// timeCodec 时间的存储格式及时区
+type timeCodec struct {
+	format   string
+	location *time.Location
+}
+
+// newTimeCodec 字段的 tag 中指定了格式或时区时优先使用，否则使用配置
+func (e *engine) newTimeCodec(tag *FieldTag) (*timeCodec, error) {
+	codec := &timeCodec{format: e.opts.TimeLayout, location: e.opts.Location}
+	if tag.TimeFormat != "" {
+		codec.format = tag.TimeFormat
+	}
+	if tag.Location != "" {
+		location, err := time.LoadLocation(tag.Location)
+		if err != nil {
+			return nil, err
+		}
+		codec.location = location
+	}
+	return codec, nil
+}
+
+func (c *timeCodec) encode(t time.Time) interface{} {
+	switch strings.ToLower(c.format) {
+	case TimeFormatUnix:
+		return t.Unix()
+	case TimeFormatUnixMilli:
+		return t.UnixMilli()
+	case TimeFormatUnixNano:
+		return t.UnixNano()
+	case TimeFormatRFC3339Nano:
+		return t.Format(time.RFC3339Nano)
+	default:
+		return t.In(c.location).Format(c.format)
+	}
+}
+
+func (c *timeCodec) decode(originVal string) (time.Time, error) {
+	switch strings.ToLower(c.format) {
+	case TimeFormatUnix, TimeFormatUnixMilli, TimeFormatUnixNano:
+		number, err := strconv.ParseInt(originVal, 10, 64)
+		if err != nil {
+			return time.Time{}, err
+		}
+		return c.fromUnix(number).In(c.location), nil
+	case TimeFormatRFC3339Nano:
+		return time.Parse(time.RFC3339Nano, originVal)
+	default:
+		return time.ParseInLocation(c.format, originVal, c.location)
+	}
+}
+
+func (c *timeCodec) fromUnix(number int64) time.Time {
+	switch strings.ToLower(c.format) {
+	case TimeFormatUnixMilli:
+		return time.UnixMilli(number)
+	case TimeFormatUnixNano:
+		return time.Unix(0, number)
+	default:
+		return time.Unix(number, 0)
+	}
+}
+
+// ----------------------------------------
+// 时间的转换，时区错误在使用时才报错，与不支持的类型一致
+// ----------------------------------------
+func (e *engine) timeEncoder(tag *FieldTag) encodeFunc {
+	codec, err := e.newTimeCodec(tag)
+	if err != nil {
+		return func(reflect.Value) (interface{}, error) {
+			return nil, timeLocationError(tag, err)
+		}
+	}
+	return func(fieldValue reflect.Value) (interface{}, error) {
+		return codec.encode(fieldValue.Interface().(time.Time)), nil
+	}
+}
+
+func (e *engine) timeDecoder(tag *FieldTag) decodeFunc {
+	codec, err := e.newTimeCodec(tag)
+	if err != nil {
+		return func(reflect.Value, string) error {
+			return timeLocationError(tag, err)
+		}
+	}
+	return func(fieldValue reflect.Value, originVal string) error {
+		timeTime, err := codec.decode(originVal)
+		if err != nil {
+			return err
+		}
+		fieldValue.Set(reflect.ValueOf(timeTime))
+		return nil
+	}
+}
+
+func timeLocationError(tag *FieldTag, err error) error {
+	errMsg := fmt.Sprintf("invalid time location name=%s location=%s", tag.Name, tag.Location)
+	return errors.Wrap(err, errMsg)
+}
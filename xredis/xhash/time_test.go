@@ -0,0 +1,126 @@
+package xhash
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTimeFormat(t *testing.T) {
+	type model struct {
+		Unix      time.Time  `redis:";time=unix"`
+		UnixMilli *time.Time `redis:";time=unixmilli"`
+		UnixNano  time.Time  `redis:";time=unixnano"`
+		Nano      time.Time  `redis:";time=rfc3339nano"`
+		Day       time.Time  `redis:";time=2006-01-02;loc=UTC"`
+		Default   time.Time
+	}
+
+	shanghai := time.FixedZone("CST", 8*3600)
+	origin := time.Date(2019, 5, 23, 10, 20, 30, 123456789, shanghai)
+	data, err := Model2map(&model{Unix: origin, UnixMilli: &origin, UnixNano: origin, Nano: origin, Day: origin, Default: origin})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data["unix"] != origin.Unix() || data["unix_milli"] != origin.UnixMilli() || data["unix_nano"] != origin.UnixNano() {
+		t.Errorf("unix time err data=%v", data)
+	}
+	if data["nano"] != "2019-05-23T10:20:30.123456789+08:00" {
+		t.Errorf("rfc3339nano time err data=%v", data)
+	}
+	if data["day"] != "2019-05-23" {
+		t.Errorf("layout time err data=%v", data)
+	}
+	if data["default"] != origin.Local().Format(DefaultTimeLayout) {
+		t.Errorf("default time err data=%v", data)
+	}
+
+	hash := map[string]string{
+		"unix":       "1558578030",
+		"unix_milli": "1558578030123",
+		"unix_nano":  "1558578030123456789",
+		"nano":       "2019-05-23T10:20:30.123456789+08:00",
+		"day":        "2019-05-23",
+	}
+	result := new(model)
+	if err := Map2model(hash, result); err != nil {
+		t.Fatal(err)
+	}
+	if !result.Unix.Equal(origin.Truncate(time.Second)) {
+		t.Errorf("unix time decode err time=%s", result.Unix)
+	}
+	if !result.UnixMilli.Equal(origin.Truncate(time.Millisecond)) {
+		t.Errorf("unix milli time decode err time=%s", result.UnixMilli)
+	}
+	if !result.UnixNano.Equal(origin) {
+		t.Errorf("unix nano time decode err time=%s", result.UnixNano)
+	}
+	// 纳秒与时区偏移都保留
+	if !result.Nano.Equal(origin) || result.Nano.Format(time.RFC3339Nano) != hash["nano"] {
+		t.Errorf("rfc3339nano time decode err time=%s", result.Nano)
+	}
+	if result.Day.Location() != time.UTC || result.Day.Day() != 23 {
+		t.Errorf("layout time decode err time=%s", result.Day)
+	}
+}
+
+// TestUnixMilliRange 毫秒时间戳不经过纳秒换算，超出 1678-2262 年也不会溢出
+func TestUnixMilliRange(t *testing.T) {
+	type model struct {
+		At time.Time `redis:";time=unixmilli"`
+	}
+	for _, origin := range []time.Time{
+		time.Date(2500, 1, 2, 3, 4, 5, 6000000, time.UTC),
+		time.Date(1500, 1, 2, 3, 4, 5, 6000000, time.UTC),
+	} {
+		data, err := Model2map(&model{At: origin})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if data["at"] != origin.UnixMilli() {
+			t.Errorf("unix milli encode err time=%s data=%v", origin, data)
+		}
+		result := new(model)
+		if err := Map2model(map[string]string{"at": formatValue(data["at"])}, result); err != nil {
+			t.Fatal(err)
+		}
+		if !result.At.Equal(origin) {
+			t.Errorf("unix milli decode err expected=%s result=%s", origin, result.At)
+		}
+	}
+}
+
+func TestTimeOptions(t *testing.T) {
+	type model struct {
+		CreatedAt time.Time
+	}
+	opts := Options{TimeLayout: TimeFormatUnixMilli, Location: time.UTC}
+	origin := time.Date(2019, 5, 23, 10, 20, 30, 123000000, time.UTC)
+
+	data, err := NewEncoder(opts).Encode(&model{CreatedAt: origin})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data["created_at"] != int64(1558606830123) {
+		t.Errorf("time option encode err data=%v", data)
+	}
+
+	result := new(model)
+	if err := NewDecoder(opts).Decode(map[string]string{"created_at": "1558606830123"}, result); err != nil {
+		t.Fatal(err)
+	}
+	if result.CreatedAt != origin {
+		t.Errorf("time option decode err time=%s", result.CreatedAt)
+	}
+}
+
+func TestTimeBadLocation(t *testing.T) {
+	type model struct {
+		CreatedAt time.Time `redis:";loc=Nowhere/Unknown"`
+	}
+	if _, err := Model2map(&model{}); err == nil {
+		t.Error("bad location should return err")
+	}
+	if err := Map2model(map[string]string{"created_at": "2019-05-23 10:20:30"}, new(model)); err == nil {
+		t.Error("bad location should return err")
+	}
+}
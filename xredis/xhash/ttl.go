@@ -0,0 +1,84 @@
+package xhash
+
+import (
+	"fmt"
+	"github.com/pkg/errors"
+	"time"
+)
+
+// Expiration 模型声明的过期时间
+type Expiration struct {
+	TTL    time.Duration            // 整个 hash 的过期时间，0 为不过期
+	Fields map[string]time.Duration // hash 字段的过期时间，key 为 hash 中的名称
+}
+
+// Empty 是否没有声明任何过期时间
+func (exp *Expiration) Empty() bool {
+	return exp.TTL == 0 && len(exp.Fields) == 0
+}
+
+// ModelExpiration 模型声明的过期时间，使用默认配置
+func ModelExpiration(model interface{}) (*Expiration, error) {
+	return defaultEncoder.Expiration(model)
+}
+
+// Expiration 模型声明的过期时间，例:
+//
+//	type Session struct {
+//		_     struct{} `redis:"ttl=24h"`
+//		Token string
+//		Code  string `redis:"code;ttl=5m"`
+//	}
+func (enc *Encoder) Expiration(model interface{}) (*Expiration, error) {
+	modelValue, err := structValue(model)
+	if err != nil {
+		return nil, err
+	}
+	info := enc.engine.cachedStructInfo(modelValue.Type())
+	if info.ttlErr != nil {
+		return nil, info.ttlErr
+	}
+	exp := &Expiration{TTL: info.ttl}
+	if len(info.fieldTTL) > 0 {
+		exp.Fields = make(map[string]time.Duration, len(info.fieldTTL))
+		for name, ttl := range info.fieldTTL {
+			exp.Fields[name] = ttl
+		}
+	}
+	return exp, nil
+}
+
+// parseTTLs 分析模型及字段的过期时间
+func parseTTLs(info *structInfo) (time.Duration, map[string]time.Duration, error) {
+	ttl, err := parseTTL(info.model.TTL)
+	if err != nil {
+		return 0, nil, err
+	}
+	var fieldTTL map[string]time.Duration
+	for _, field := range info.fields {
+		if field.tag.TTL == "" {
+			continue
+		}
+		value, err := parseTTL(field.tag.TTL)
+		if err != nil {
+			return 0, nil, errors.Wrapf(err, "field=%s", field.path)
+		}
+		if fieldTTL == nil {
+			fieldTTL = make(map[string]time.Duration)
+		}
+		fieldTTL[field.name] = value
+	}
+	return ttl, fieldTTL, nil
+}
+
+func parseTTL(value string) (time.Duration, error) {
+	if value == "" {
+		return 0, nil
+	}
+	ttl, err := time.ParseDuration(value)
+	if err != nil || ttl <= 0 {
+		errMsg := fmt.Sprintf("invalid ttl value=%s", value)
+		return 0, errors.New(errMsg)
+	}
+	return ttl, nil
+}
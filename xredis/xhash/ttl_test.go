@@ -0,0 +1,43 @@
+package xhash
+
+import (
+	"testing"
+	"time"
+)
+
+type ttlSession struct {
+	_     struct{} `redis:"ttl=24h"`
+	Token string
+	Code  string `redis:"code;ttl=5m"`
+	Name  string
+}
+
+func TestExpiration(t *testing.T) {
+	exp, err := ModelExpiration(&ttlSession{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exp.TTL != 24*time.Hour || len(exp.Fields) != 1 || exp.Fields["code"] != 5*time.Minute {
+		t.Errorf("expiration err exp=%v", exp)
+	}
+
+	exp, err = ModelExpiration(&keyUser{})
+	if err != nil || !exp.Empty() {
+		t.Errorf("empty expiration err exp=%v err=%v", exp, err)
+	}
+
+	type invalidTTL struct {
+		_    struct{} `redis:"ttl=abc"`
+		Name string
+	}
+	if _, err := ModelExpiration(&invalidTTL{}); err == nil {
+		t.Errorf("invalid model ttl should fail")
+	}
+
+	type negativeTTL struct {
+		Name string `redis:";ttl=-1s"`
+	}
+	if _, err := ModelExpiration(&negativeTTL{}); err == nil {
+		t.Errorf("negative field ttl should fail")
+	}
+}
@@ -0,0 +1,108 @@
+package xhash
+
+import (
+	"fmt"
+	"github.com/pkg/errors"
+	"math"
+	"reflect"
+)
+
+// ErrNoVersion 模型没有带 version 选项的字段
+var ErrNoVersion = errors.New("model has no version field")
+
+// Version 模型版本字段在 hash 中的名称及当前的值，使用默认配置
+func Version(model interface{}) (key string, version int64, err error) {
+	return defaultEncoder.Version(model)
+}
+
+// SetVersion 设置模型版本字段的值，使用默认配置
+func SetVersion(model interface{}, version int64) error {
+	return defaultDecoder.SetVersion(model, version)
+}
+
+// Version 模型版本字段在 hash 中的名称及当前的值，没有版本字段时返回 ErrNoVersion
+func (enc *Encoder) Version(model interface{}) (key string, version int64, err error) {
+	modelValue, field, err := versionValue(enc.engine, model)
+	if err != nil {
+		return "", 0, err
+	}
+	fieldValue, _ := fieldByIndex(modelValue, field.index, false)
+	if !fieldValue.IsValid() {
+		return field.name, 0, nil
+	}
+	switch fieldValue.Kind() {
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		if fieldValue.Uint() > math.MaxInt64 {
+			errMsg := fmt.Sprintf("version out of range name=%s value=%d", field.path, fieldValue.Uint())
+			return "", 0, errors.New(errMsg)
+		}
+		return field.name, int64(fieldValue.Uint()), nil
+	}
+	return field.name, fieldValue.Int(), nil
+}
+
+// SetVersion 设置模型版本字段的值，用于写入成功后同步 hash 中的版本
+func (dec *Decoder) SetVersion(model interface{}, version int64) error {
+	modelValue, field, err := versionValue(dec.engine, model)
+	if err != nil {
+		return err
+	}
+	fieldValue, _ := fieldByIndex(modelValue, field.index, true)
+	switch fieldValue.Kind() {
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		if version < 0 || fieldValue.OverflowUint(uint64(version)) {
+			return versionRangeError(fieldValue.Type(), version)
+		}
+		fieldValue.SetUint(uint64(version))
+	default:
+		if fieldValue.OverflowInt(version) {
+			return versionRangeError(fieldValue.Type(), version)
+		}
+		fieldValue.SetInt(version)
+	}
+	return nil
+}
+
+func versionRangeError(t reflect.Type, version int64) error {
+	errMsg := fmt.Sprintf("version out of range for %s value=%d", t, version)
+	return errors.New(errMsg)
+}
+
+// versionValue 检查模型并取出版本字段
+func versionValue(e *engine, model interface{}) (reflect.Value, *fieldInfo, error) {
+	modelValue, err := structValue(model)
+	if err != nil {
+		return reflect.Value{}, nil, err
+	}
+	info := e.cachedStructInfo(modelValue.Type())
+	if info.verErr != nil {
+		return reflect.Value{}, nil, info.verErr
+	}
+	if info.verField == nil {
+		return reflect.Value{}, nil, ErrNoVersion
+	}
+	return modelValue, info.verField, nil
+}
+
+// versionField 找出带 version 选项的字段，只允许一个，且必须是整数
+func versionField(info *structInfo) (*fieldInfo, error) {
+	var result *fieldInfo
+	for _, field := range info.fields {
+		if !field.tag.Version {
+			continue
+		}
+		if result != nil {
+			errMsg := fmt.Sprintf("multiple version fields name=%s,%s", result.path, field.path)
+			return nil, errors.New(errMsg)
+		}
+		switch field.field.Type.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		default:
+			errMsg := fmt.Sprintf("version field must be an integer name=%s type=%s", field.path, field.field.Type)
+			return nil, errors.New(errMsg)
+		}
+		result = field
+	}
+	return result, nil
+}
@@ -0,0 +1,55 @@
+package xhash
+
+import (
+	"testing"
+)
+
+type versionUser struct {
+	Id      int64
+	Version uint32 `redis:"ver;version"`
+}
+
+func TestVersion(t *testing.T) {
+	user := &versionUser{Id: 1, Version: 3}
+	key, version, err := Version(user)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if key != "ver" || version != 3 {
+		t.Errorf("version err key=%s version=%d", key, version)
+	}
+
+	if err := SetVersion(user, 4); err != nil || user.Version != 4 {
+		t.Errorf("set version err version=%d err=%v", user.Version, err)
+	}
+	if err := SetVersion(user, -1); err == nil {
+		t.Errorf("set negative version should fail")
+	}
+
+	// 版本字段仍然正常写入与读取
+	data, err := Model2map(user)
+	if err != nil || data["ver"] != uint64(4) {
+		t.Errorf("encode version err data=%v err=%v", data, err)
+	}
+}
+
+func TestVersionError(t *testing.T) {
+	if _, _, err := Version(&keyUser{}); err != ErrNoVersion {
+		t.Errorf("no version err=%v", err)
+	}
+
+	type stringVersion struct {
+		Version string `redis:";version"`
+	}
+	if _, _, err := Version(&stringVersion{}); err == nil {
+		t.Errorf("string version should fail")
+	}
+
+	type twoVersions struct {
+		A int64 `redis:";version"`
+		B int64 `redis:";version"`
+	}
+	if _, _, err := Version(&twoVersions{}); err == nil {
+		t.Errorf("multiple versions should fail")
+	}
+}
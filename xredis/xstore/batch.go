@@ -0,0 +1,149 @@
+package xstore
+
+import (
+	"context"
+	"fmt"
+	"github.com/pkg/errors"
+	"github.com/redis/go-redis/v9"
+)
+
+// DefaultBatchSize 批量操作时每个 pipeline 默认包含的 key 数量
+const DefaultBatchSize = 100
+
+// BatchResult 批量操作中单个 key 的结果
+type BatchResult struct {
+	Key   string
+	Found bool  // 读取时 key 是否存在
+	Err   error // 该 key 的命令或转换错误
+}
+
+// LoadMany 用 pipeline 批量 HGETALL，返回与 keys 一一对应的模型及结果，不存在或失败的 key 模型为 nil
+func LoadMany[T any](ctx context.Context, s *Store, keys []string) ([]*T, []BatchResult) {
+	return loadMany[T](ctx, s, keys, nil)
+}
+
+// LoadManyFields 用 pipeline 批量 HMGET，只读取模型需要的字段，fieldNames 的含义同 Store.LoadFields
+func LoadManyFields[T any](ctx context.Context, s *Store, keys []string, fieldNames ...string) ([]*T, []BatchResult) {
+	if len(fieldNames) == 0 {
+		fieldNames = []string{}
+	}
+	return loadMany[T](ctx, s, keys, fieldNames)
+}
+
+// LoadMap 同 LoadMany，只返回存在且转换成功的模型，按 key 索引
+func LoadMap[T any](ctx context.Context, s *Store, keys []string) (map[string]*T, []BatchResult) {
+	models, results := LoadMany[T](ctx, s, keys)
+	found := make(map[string]*T, len(keys))
+	for i, model := range models {
+		if model != nil {
+			found[keys[i]] = model
+		}
+	}
+	return found, results
+}
+
+// SaveMany 用 pipeline 批量写入，keys 与 models 一一对应，规则同 Store.Save，
+// 模型声明了过期时间时在同一个 pipeline 中设置，pipeline 不是事务，写入成功而设置过期时间失败时 Err 不为空
+func SaveMany[T any](ctx context.Context, s *Store, keys []string, models []*T) ([]BatchResult, error) {
+	if len(keys) != len(models) {
+		errMsg := fmt.Sprintf("keys and models count mismatch keys=%d models=%d", len(keys), len(models))
+		return nil, errors.New(errMsg)
+	}
+
+	results := newBatchResults(keys)
+	for _, chunk := range s.chunks(keys) {
+		cmds := make(map[int][]redis.Cmder, len(chunk))
+		_, _ = s.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+			for _, i := range chunk {
+				changes, err := s.encoder.EncodeChanges(models[i])
+				if err != nil {
+					results[i].Err = err
+					continue
+				}
+				exp, err := s.expiration(models[i])
+				if err != nil {
+					results[i].Err = err
+					continue
+				}
+				version, err := s.takeVersion(models[i], changes)
+				if err != nil {
+					results[i].Err = err
+					continue
+				}
+				if changes.Empty() {
+					continue
+				}
+				cmds[i] = s.queueWrite(ctx, pipe, keys[i], changes, exp, version)
+			}
+			return nil
+		})
+		for i, list := range cmds {
+			for _, cmd := range list {
+				if err := cmd.Err(); err != nil && results[i].Err == nil {
+					results[i].Err = ttlError(err)
+				}
+			}
+		}
+	}
+	return results, nil
+}
+
+// loadMany fieldNames 为 nil 时使用 HGETALL，否则使用 HMGET
+func loadMany[T any](ctx context.Context, s *Store, keys []string, fieldNames []string) ([]*T, []BatchResult) {
+	models := make([]*T, len(keys))
+	results := newBatchResults(keys)
+
+	var fieldKeys []string
+	if fieldNames != nil {
+		var err error
+		fieldKeys, err = s.decoder.FieldKeys(new(T), fieldNames...)
+		if err != nil {
+			for i := range results {
+				results[i].Err = err
+			}
+			return models, results
+		}
+	}
+
+	for _, chunk := range s.chunks(keys) {
+		cmds := make([]redis.Cmder, len(chunk))
+		_, _ = s.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+			for j, i := range chunk {
+				if fieldNames == nil {
+					cmds[j] = pipe.HGetAll(ctx, keys[i])
+				} else {
+					cmds[j] = pipe.HMGet(ctx, keys[i], fieldKeys...)
+				}
+			}
+			return nil
+		})
+
+		for j, i := range chunk {
+			model := new(T)
+			switch cmd := cmds[j].(type) {
+			case *redis.MapStringStringCmd:
+				results[i].Found, results[i].Err = s.ScanHash(cmd, model)
+			case *redis.SliceCmd:
+				results[i].Found, results[i].Err = s.ScanValues(cmd, model, fieldNames...)
+			}
+			if results[i].Found && results[i].Err == nil {
+				models[i] = model
+			}
+		}
+	}
+	return models, results
+}
+
+// chunks 按配置的大小分批，集群客户端按 slot 排序后再分批
+func (s *Store) chunks(keys []string) [][]int {
+	_, isCluster := s.client.(*redis.ClusterClient)
+	return chunkKeys(keys, s.batchSize, isCluster)
+}
+
+func newBatchResults(keys []string) []BatchResult {
+	results := make([]BatchResult, len(keys))
+	for i, key := range keys {
+		results[i].Key = key
+	}
+	return results
+}
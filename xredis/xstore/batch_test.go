@@ -0,0 +1,112 @@
+package xstore
+
+import (
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/suite"
+	"testing"
+)
+
+type BatchTestSuite struct {
+	suite.Suite
+	server *miniredis.Miniredis
+	store  *Store
+}
+
+func (s *BatchTestSuite) SetupTest() {
+	s.server, s.store = newTestStore(s.T(), Options{BatchSize: 2})
+}
+
+// 测试批量写入与读取，批次大小小于 key 数量
+func (s *BatchTestSuite) TestSaveLoadMany() {
+	keys := []string{"user:1", "user:2", "user:3"}
+	users := []*storeUser{newStoreUser(), newStoreUser(), newStoreUser()}
+	users[1].Name = "wade"
+	users[2].Name = "james"
+
+	results, err := SaveMany(ctx, s.store, keys, users)
+	s.Nil(err)
+	for _, result := range results {
+		s.Nil(result.Err, "test save many err key=%s", result.Key)
+	}
+	s.Equal(s.server.HGet("user:3", "name"), "james", "test save many value err")
+
+	models, results := LoadMany[storeUser](ctx, s.store, []string{"user:1", "user:404", "user:3"})
+	s.Equal(models[0], users[0], "test load many value err")
+	s.Nil(models[1], "test load many missing model err")
+	s.Equal(models[2], users[2], "test load many value err")
+	s.True(results[0].Found)
+	s.False(results[1].Found, "test load many missing found err")
+	s.Nil(results[1].Err)
+	s.Equal(results[2].Key, "user:3", "test load many result key err")
+}
+
+// 测试按 key 返回
+func (s *BatchTestSuite) TestLoadMap() {
+	_, err := SaveMany(ctx, s.store, []string{"user:1"}, []*storeUser{newStoreUser()})
+	s.Nil(err)
+
+	models, results := LoadMap[storeUser](ctx, s.store, []string{"user:1", "user:404"})
+	s.Len(models, 1, "test load map count err")
+	s.Equal(models["user:1"], newStoreUser(), "test load map value err")
+	s.Len(results, 2, "test load map results err")
+}
+
+// 测试批量读取部分字段
+func (s *BatchTestSuite) TestLoadManyFields() {
+	_, err := SaveMany(ctx, s.store, []string{"user:1", "user:2"}, []*storeUser{newStoreUser(), newStoreUser()})
+	s.Nil(err)
+
+	models, results := LoadManyFields[storeUser](ctx, s.store, []string{"user:1", "user:2", "user:404"}, "Name")
+	s.Equal(models[0], &storeUser{Name: "william"}, "test load many fields value err")
+	s.Equal(models[1], &storeUser{Name: "william"}, "test load many fields value err")
+	s.Nil(models[2])
+	s.False(results[2].Found, "test load many fields missing err")
+
+	_, results = LoadManyFields[storeUser](ctx, s.store, []string{"user:1"}, "Unknown")
+	s.NotNil(results[0].Err, "test load many unknown field err")
+}
+
+// 测试单个 key 出错不影响其他 key
+func (s *BatchTestSuite) TestPerKeyError() {
+	_, err := SaveMany(ctx, s.store, []string{"user:1"}, []*storeUser{newStoreUser()})
+	s.Nil(err)
+	s.server.Set("user:2", "not a hash")
+	s.server.HSet("user:3", "id", "abc")
+
+	models, results := LoadMany[storeUser](ctx, s.store, []string{"user:1", "user:2", "user:3"})
+	s.NotNil(models[0])
+	s.Nil(results[0].Err)
+	s.Nil(models[1])
+	s.NotNil(results[1].Err, "test wrong type err")
+	s.Nil(models[2])
+	s.True(results[2].Found)
+	s.NotNil(results[2].Err, "test decode err")
+
+	_, err = SaveMany(ctx, s.store, []string{"user:1"}, []*storeUser{})
+	s.NotNil(err, "test save many count mismatch err")
+}
+
+func TestBatchSuite(t *testing.T) {
+	suite.Run(t, new(BatchTestSuite))
+}
+
+// 测试 slot 计算
+func TestHashSlot(t *testing.T) {
+	if slot := hashSlot("123456789"); slot != 12739 {
+		t.Errorf("test hash slot err slot=%d", slot)
+	}
+	if hashSlot("{user1000}.following") != hashSlot("{user1000}.followers") {
+		t.Errorf("test hash tag slot err")
+	}
+	if hashSlot("foo{}bar") != int(crc16("foo{}bar"))%slotNumber {
+		t.Errorf("test empty hash tag slot err")
+	}
+
+	keys := []string{"{b}1", "{a}1", "{b}2", "{a}2"}
+	chunks := chunkKeys(keys, 2, true)
+	for _, chunk := range chunks {
+		if hashSlot(keys[chunk[0]]) != hashSlot(keys[chunk[1]]) {
+			t.Errorf("test chunk by slot err chunk=%v", chunk)
+		}
+	}
+}
@@ -0,0 +1,38 @@
+package xstore
+
+import (
+	"context"
+	"github.com/wanghuida/go-redis-ext/xredis/xhash"
+)
+
+// ----------------------------------------
+// 只写入发生变化的字段，例:
+//
+//	found, err := store.Load(ctx, key, user)
+//	snapshot, err := store.Snapshot(user)
+//	user.Score++
+//	err = store.SaveChanges(ctx, key, user, snapshot)
+// ----------------------------------------
+
+// Snapshot 记录模型当前的状态
+func (s *Store) Snapshot(model interface{}) (xhash.Snapshot, error) {
+	return s.encoder.Snapshot(model)
+}
+
+// SaveChanges 与 snapshot 相比，变化的字段用 HSET 写入，变为 nil 的字段用 HDEL 删除，
+// 两者都有时在一个事务中执行；成功后 snapshot 更新为保存后的状态，可以继续使用
+func (s *Store) SaveChanges(ctx context.Context, key string, model interface{}, snapshot xhash.Snapshot) error {
+	changes, err := s.encoder.Diff(snapshot, model)
+	if err != nil {
+		return err
+	}
+	exp, err := s.expiration(model)
+	if err != nil {
+		return err
+	}
+	if err := s.write(ctx, key, model, changes, exp); err != nil {
+		return err
+	}
+	snapshot.Apply(changes)
+	return nil
+}
@@ -0,0 +1,103 @@
+package xstore
+
+import (
+	"context"
+	"fmt"
+	"github.com/pkg/errors"
+	"github.com/redis/go-redis/v9"
+	"github.com/wanghuida/go-redis-ext/xredis/xhash"
+)
+
+// IncrBy 用 HINCRBY 原子增减 Go 字段对应的 hash 字段，并将新的值填充到模型，字段需要是整数；
+// 模型声明了过期时间时在同一个事务中设置
+func (s *Store) IncrBy(ctx context.Context, key string, model interface{}, fieldName string, delta int64) error {
+	counter, err := s.counter(model, fieldName, false)
+	if err != nil {
+		return err
+	}
+	exp, err := s.expiration(model)
+	if err != nil {
+		return err
+	}
+	var cmd *redis.IntCmd
+	err = s.incr(ctx, key, counter, exp, func(pipe redis.Cmdable) redis.Cmder {
+		cmd = pipe.HIncrBy(ctx, key, counter.Key, delta)
+		return cmd
+	})
+	if err != nil {
+		return err
+	}
+	return s.decoder.DecodeValues([]interface{}{cmd.Val()}, model, counter.Path)
+}
+
+// IncrByFloat 用 HINCRBYFLOAT 原子增减 Go 字段对应的 hash 字段，并将新的值填充到模型，字段需要是浮点数；
+// 模型声明了过期时间时在同一个事务中设置
+func (s *Store) IncrByFloat(ctx context.Context, key string, model interface{}, fieldName string, delta float64) error {
+	counter, err := s.counter(model, fieldName, true)
+	if err != nil {
+		return err
+	}
+	exp, err := s.expiration(model)
+	if err != nil {
+		return err
+	}
+	var cmd *redis.FloatCmd
+	err = s.incr(ctx, key, counter, exp, func(pipe redis.Cmdable) redis.Cmder {
+		cmd = pipe.HIncrByFloat(ctx, key, counter.Key, delta)
+		return cmd
+	})
+	if err != nil {
+		return err
+	}
+	return s.decoder.DecodeValues([]interface{}{cmd.Val()}, model, counter.Path)
+}
+
+// IncrFieldBy 同 IncrBy，用字段指针指定字段，例: store.IncrFieldBy(ctx, key, user, &user.Id, 1)
+func (s *Store) IncrFieldBy(ctx context.Context, key string, model interface{}, fieldPtr interface{}, delta int64) error {
+	fieldName, err := s.decoder.FieldName(model, fieldPtr)
+	if err != nil {
+		return err
+	}
+	return s.IncrBy(ctx, key, model, fieldName, delta)
+}
+
+// IncrFieldByFloat 同 IncrByFloat，用字段指针指定字段，例: store.IncrFieldByFloat(ctx, key, user, &user.Score, 1.5)
+func (s *Store) IncrFieldByFloat(ctx context.Context, key string, model interface{}, fieldPtr interface{}, delta float64) error {
+	fieldName, err := s.decoder.FieldName(model, fieldPtr)
+	if err != nil {
+		return err
+	}
+	return s.IncrByFloat(ctx, key, model, fieldName, delta)
+}
+
+// counter 查找计数字段，整数字段只能用 HINCRBY，浮点数字段只能用 HINCRBYFLOAT
+func (s *Store) counter(model interface{}, fieldName string, float bool) (*xhash.CounterField, error) {
+	counter, err := s.decoder.Counter(model, fieldName)
+	if err != nil {
+		return nil, err
+	}
+	if counter.Float != float {
+		kind, use := "integer", "IncrBy"
+		if counter.Float {
+			kind, use = "float", "IncrByFloat"
+		}
+		errMsg := fmt.Sprintf("%s counter needs %s name=%s", kind, use, counter.Path)
+		return nil, errors.New(errMsg)
+	}
+	return counter, nil
+}
+
+// incr 执行增减的命令，模型声明了过期时间或需要删除不存在的标记时与之在一个事务中执行
+func (s *Store) incr(ctx context.Context, key string, counter *xhash.CounterField, exp *xhash.Expiration, queue func(pipe redis.Cmdable) redis.Cmder) error {
+	if exp.Empty() && s.negativeTTL <= 0 {
+		return queue(s.client).Err()
+	}
+	changes := &xhash.Changes{Set: map[string]interface{}{counter.Key: nil}}
+	_, err := s.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		s.clearNotFound(ctx, pipe, key)
+		queue(pipe)
+		expire(ctx, pipe, key, changes, exp)
+		return nil
+	})
+	return writeError(err, changes, exp)
+}
@@ -0,0 +1,194 @@
+package xstore
+
+import (
+	"context"
+	"errors"
+	"github.com/redis/go-redis/v9"
+	"github.com/wanghuida/go-redis-ext/xredis/xhash"
+	"golang.org/x/sync/singleflight"
+	"math/rand"
+	"reflect"
+	"time"
+)
+
+// ----------------------------------------
+// 旁路缓存，先读 hash，不存在时回源读取并写入缓存，例:
+//
+//	user := &model.User{Id: 1}
+//	found, err := store.GetOrLoad(ctx, "user:1", user, func(ctx context.Context, model interface{}) error {
+//		return db.WithContext(ctx).First(model, 1).Error // 不存在时返回 xstore.ErrNotFound
+//	})
+//
+// 数据源更新后调用 Delete 让缓存失效
+// ----------------------------------------
+
+// ErrNotFound 回源函数返回该错误表示数据源中也不存在
+var ErrNotFound = errors.New("not found")
+
+// DefaultLoadTimeout 回源及写入缓存默认的超时时间
+const DefaultLoadTimeout = 10 * time.Second
+
+// NotFoundField 缓存数据源中不存在时写入的标记字段，只有该字段的 hash 视为不存在
+const NotFoundField = "\x00notfound"
+
+// clearNotFoundScript 删除不存在的标记，标记存在时同时去掉它的过期时间，之后写入的数据不会随标记一起过期；
+// 写入时在同一个事务中最先执行，模型声明的过期时间之后重新设置
+// KEYS[1] hash 的 key，ARGV[1] 标记字段
+var clearNotFoundScript = redis.NewScript(`
+if redis.call('HDEL', KEYS[1], ARGV[1]) == 1 then
+	return redis.call('PERSIST', KEYS[1])
+end
+return 0
+`)
+
+// LoaderFunc 回源读取数据填充到 model，model 是与调用方相同类型的新模型；
+// 数据源中不存在时返回 ErrNotFound
+type LoaderFunc func(ctx context.Context, model interface{}) error
+
+// GetOrLoad 读取 hash 填充到模型，不存在时调用 loader 回源，并写入缓存；
+// 同一个 key 并发的回源只会执行一次，回源使用的 ctx 保留调用方的值，但不受调用方取消的影响，超时时间为 LoadTimeout；
+// 调用方的 ctx 取消时直接返回 ctx 的错误，回源继续执行；配置了 NegativeTTL 时数据源中不存在的结果也会缓存；
+// 缓存与数据源中都不存在时 found 为 false，模型保持不变
+func (s *Store) GetOrLoad(ctx context.Context, key string, model interface{}, loader LoaderFunc) (found bool, err error) {
+	fields, err := s.client.HGetAll(ctx, key).Result()
+	if err != nil {
+		return false, err
+	}
+	if isNotFound(fields) {
+		return false, nil
+	}
+	if len(fields) > 0 {
+		return true, s.decoder.Decode(fields, model)
+	}
+
+	modelType := reflect.TypeOf(model)
+	if modelType == nil || modelType.Kind() != reflect.Ptr {
+		return false, &xhash.InvalidTargetError{Type: modelType}
+	}
+	results := s.loading.DoChan(key, func() (interface{}, error) {
+		loadCtx, cancel := context.WithTimeout(valueOnlyContext{ctx}, s.loadTimeout)
+		defer cancel()
+		return s.load(loadCtx, key, reflect.New(modelType.Elem()).Interface(), loader)
+	})
+	var result singleflight.Result
+	select {
+	case <-ctx.Done():
+		return false, ctx.Err()
+	case result = <-results:
+	}
+	if result.Err != nil {
+		return false, result.Err
+	}
+	// 并发的调用方共享回源的结果，各自解析一份，互不影响
+	snapshot := result.Val.(xhash.Snapshot)
+	if snapshot == nil {
+		return false, nil
+	}
+	return true, s.decoder.Decode(snapshot, model)
+}
+
+// load 回源读取并写入缓存，数据源中不存在时返回 nil；
+// 先重新读取一次缓存，刚结束的回源已经写入时不再重复回源
+func (s *Store) load(ctx context.Context, key string, model interface{}, loader LoaderFunc) (xhash.Snapshot, error) {
+	fields, err := s.client.HGetAll(ctx, key).Result()
+	if err != nil {
+		return nil, err
+	}
+	if isNotFound(fields) {
+		return nil, nil
+	}
+	if len(fields) > 0 {
+		return fields, nil
+	}
+
+	err = loader(ctx, model)
+	if errors.Is(err, ErrNotFound) {
+		if s.negativeTTL > 0 {
+			_, err = s.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+				pipe.HSet(ctx, key, NotFoundField, "1")
+				pipe.PExpire(ctx, key, s.jitter(s.negativeTTL))
+				return nil
+			})
+		}
+		return nil, err
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	changes, err := s.encoder.EncodeChanges(model)
+	if err != nil {
+		return nil, err
+	}
+	exp, err := s.expiration(model)
+	if err != nil {
+		return nil, err
+	}
+	if s.loadTTL > 0 {
+		exp.TTL = s.loadTTL
+	}
+	exp.TTL = s.jitter(exp.TTL)
+	if err := s.write(ctx, key, model, changes, exp); err != nil {
+		return nil, err
+	}
+	return s.encoder.Snapshot(model)
+}
+
+// jitter 过期时间随机增加 [0, TTLJitter)，避免同一批写入的 key 同时过期
+func (s *Store) jitter(ttl time.Duration) time.Duration {
+	if ttl <= 0 || s.ttlJitter <= 0 {
+		return ttl
+	}
+	return ttl + time.Duration(rand.Int63n(int64(s.ttlJitter)))
+}
+
+// existsScript hash 存在且不是只有不存在的标记时返回 1
+// KEYS[1] hash 的 key，ARGV[1] 标记字段
+var existsScript = redis.NewScript(`
+if redis.call('EXISTS', KEYS[1]) == 0 then
+	return 0
+end
+if redis.call('TYPE', KEYS[1]).ok == 'hash' and redis.call('HLEN', KEYS[1]) == 1 and redis.call('HEXISTS', KEYS[1], ARGV[1]) == 1 then
+	return 0
+end
+return 1
+`)
+
+// notFoundMarker 配置了 NegativeTTL 时返回不存在的标记字段，否则返回空字符串，写入时不需要处理标记
+func (s *Store) notFoundMarker() string {
+	if s.negativeTTL <= 0 {
+		return ""
+	}
+	return NotFoundField
+}
+
+// clearNotFound 配置了 NegativeTTL 时将删除不存在标记的命令加入 pipeline，否则返回 nil
+func (s *Store) clearNotFound(ctx context.Context, pipe redis.Pipeliner, key string) redis.Cmder {
+	if s.negativeTTL <= 0 {
+		return nil
+	}
+	return clearNotFoundScript.Eval(ctx, pipe, []string{key}, NotFoundField)
+}
+
+// valueOnlyContext 只保留 ctx 中的值，不继承截止时间及取消
+type valueOnlyContext struct {
+	context.Context
+}
+
+func (valueOnlyContext) Deadline() (deadline time.Time, ok bool) {
+	return time.Time{}, false
+}
+
+func (valueOnlyContext) Done() <-chan struct{} {
+	return nil
+}
+
+func (valueOnlyContext) Err() error {
+	return nil
+}
+
+// isNotFound 是否为数据源中不存在的标记
+func isNotFound(fields map[string]string) bool {
+	_, ok := fields[NotFoundField]
+	return ok && len(fields) == 1
+}
@@ -0,0 +1,248 @@
+package xstore
+
+import (
+	"context"
+	"errors"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/suite"
+	"github.com/wanghuida/go-redis-ext/xredis/xhash"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type LoaderTestSuite struct {
+	suite.Suite
+	server *miniredis.Miniredis
+	store  *Store
+}
+
+func (s *LoaderTestSuite) SetupTest() {
+	s.server, s.store = newTestStore(s.T(), Options{
+		LoadTTL:     time.Hour,
+		NegativeTTL: time.Minute,
+		TTLJitter:   time.Second,
+	})
+}
+
+// 测试回源并写入缓存
+func (s *LoaderTestSuite) TestGetOrLoad() {
+	var calls int32
+	loader := func(ctx context.Context, model interface{}) error {
+		atomic.AddInt32(&calls, 1)
+		*model.(*storeUser) = *newStoreUser()
+		return nil
+	}
+
+	user := new(storeUser)
+	found, err := s.store.GetOrLoad(ctx, "user:1", user, loader)
+	s.Nil(err)
+	s.True(found)
+	s.Equal(user, newStoreUser(), "test get or load value err")
+	s.Equal(s.server.HGet("user:1", "name"), "william", "test get or load cache err")
+	ttl := s.server.TTL("user:1")
+	s.True(ttl >= time.Hour && ttl < time.Hour+time.Second, "test get or load ttl err ttl=%v", ttl)
+
+	// 缓存存在时不回源
+	user = new(storeUser)
+	found, err = s.store.GetOrLoad(ctx, "user:1", user, loader)
+	s.Nil(err)
+	s.True(found)
+	s.Equal(user, newStoreUser())
+	s.Equal(atomic.LoadInt32(&calls), int32(1), "test get or load hit err")
+}
+
+// 测试缓存不存在的结果
+func (s *LoaderTestSuite) TestNegativeCache() {
+	var calls int32
+	loader := func(ctx context.Context, model interface{}) error {
+		atomic.AddInt32(&calls, 1)
+		return ErrNotFound
+	}
+
+	user := &storeUser{Name: "keep"}
+	found, err := s.store.GetOrLoad(ctx, "user:404", user, loader)
+	s.Nil(err)
+	s.False(found)
+	s.Equal(user.Name, "keep", "test not found should not touch model")
+	s.Equal(s.server.HGet("user:404", NotFoundField), "1", "test negative cache err")
+	s.True(s.server.TTL("user:404") >= time.Minute, "test negative cache ttl err")
+
+	found, err = s.store.GetOrLoad(ctx, "user:404", user, loader)
+	s.Nil(err)
+	s.False(found)
+	s.Equal(atomic.LoadInt32(&calls), int32(1), "test negative cache hit err")
+
+	// 其他读取方式也视为不存在
+	found, err = s.store.Load(ctx, "user:404", user)
+	s.Nil(err)
+	s.False(found, "test load negative cache err")
+}
+
+// 测试只有不存在标记的 hash 视为不存在
+func (s *LoaderTestSuite) TestExistsNegativeCache() {
+	found, err := s.store.GetOrLoad(ctx, "user:404", new(storeUser), func(ctx context.Context, model interface{}) error {
+		return ErrNotFound
+	})
+	s.Nil(err)
+	s.False(found)
+	s.True(s.server.Exists("user:404"))
+
+	exists, err := s.store.Exists(ctx, "user:404")
+	s.Nil(err)
+	s.False(exists, "test exists negative cache err")
+
+	s.Nil(s.store.Save(ctx, "user:404", newStoreUser()))
+	exists, err = s.store.Exists(ctx, "user:404")
+	s.Nil(err)
+	s.True(exists, "test exists after save err")
+
+	s.Nil(s.server.Set("user:string", "value"))
+	exists, err = s.store.Exists(ctx, "user:string")
+	s.Nil(err)
+	s.True(exists, "test exists other type err")
+
+	exists, err = s.store.Exists(ctx, "user:missing")
+	s.Nil(err)
+	s.False(exists, "test exists missing err")
+}
+
+// 测试写入后删除不存在的标记
+func (s *LoaderTestSuite) TestNegativeCacheCleared() {
+	notFound := func(ctx context.Context, model interface{}) error {
+		return ErrNotFound
+	}
+	strict := NewStore(s.store.Client(), Options{Decoder: xhash.NewDecoder(xhash.Options{Strict: true})})
+	writes := map[string]func(key string) error{
+		"save": func(key string) error {
+			return s.store.Save(ctx, key, &versionUser{Id: 1, Name: "william"})
+		},
+		"save many": func(key string) error {
+			results, err := SaveMany(ctx, s.store, []string{key}, []*versionUser{{Id: 1, Name: "william"}})
+			if err != nil {
+				return err
+			}
+			return results[0].Err
+		},
+		"save versioned": func(key string) error {
+			return s.store.SaveVersioned(ctx, key, &versionUser{Id: 1, Name: "william"})
+		},
+		"incr": func(key string) error {
+			return s.store.IncrBy(ctx, key, new(versionUser), "Id", 1)
+		},
+	}
+	for name, write := range writes {
+		key := "user:" + name
+		found, err := s.store.GetOrLoad(ctx, key, new(versionUser), notFound)
+		s.Nil(err)
+		s.False(found)
+		s.True(s.server.Exists(key), "test %s negative cache err", name)
+
+		s.Nil(write(key), "test %s write err", name)
+		s.False(s.server.Exists(key) && s.server.HGet(key, NotFoundField) != "", "test %s marker not cleared", name)
+		s.Equal(s.server.TTL(key), time.Duration(0), "test %s ttl not cleared", name)
+
+		found, err = strict.Load(ctx, key, new(versionUser))
+		s.Nil(err, "test %s strict load err", name)
+		s.True(found, "test %s load after write err", name)
+	}
+
+	// 模型声明的过期时间在删除标记后重新设置
+	key := "counter:404"
+	_, err := s.store.GetOrLoad(ctx, key, new(ttlCounter), notFound)
+	s.Nil(err)
+	s.True(s.server.TTL(key) >= time.Minute)
+	s.Nil(s.store.IncrBy(ctx, key, new(ttlCounter), "Hits", 1))
+	s.Equal(s.server.TTL(key), time.Hour, "test incr ttl after negative cache err")
+	s.Equal(s.server.HGet(key, NotFoundField), "")
+}
+
+// 测试回源失败
+func (s *LoaderTestSuite) TestLoaderError() {
+	loadErr := errors.New("db down")
+	_, err := s.store.GetOrLoad(ctx, "user:1", new(storeUser), func(ctx context.Context, model interface{}) error {
+		return loadErr
+	})
+	s.Equal(err, loadErr)
+	s.False(s.server.Exists("user:1"), "test loader error should not cache")
+}
+
+// 测试并发回源只执行一次
+func (s *LoaderTestSuite) TestSingleflight() {
+	var calls int32
+	var started sync.WaitGroup
+	loader := func(ctx context.Context, model interface{}) error {
+		atomic.AddInt32(&calls, 1)
+		// 等待所有调用方开始后再返回，尽量让它们合并到同一次回源；
+		// 没有赶上的调用方会在回源前重新读到缓存，不会再次回源
+		started.Wait()
+		*model.(*storeUser) = *newStoreUser()
+		return nil
+	}
+
+	var wg sync.WaitGroup
+	users := make([]*storeUser, 10)
+	for i := range users {
+		users[i] = new(storeUser)
+		wg.Add(1)
+		started.Add(1)
+		go func(user *storeUser) {
+			defer wg.Done()
+			started.Done()
+			found, err := s.store.GetOrLoad(ctx, "user:1", user, loader)
+			s.Nil(err)
+			s.True(found)
+		}(users[i])
+	}
+	wg.Wait()
+
+	s.Equal(atomic.LoadInt32(&calls), int32(1), "test singleflight calls err")
+	for _, user := range users {
+		s.Equal(user, newStoreUser(), "test singleflight value err")
+	}
+	// 各自解析，互不影响
+	users[0].Tags[0] = "changed"
+	s.Equal(users[1].Tags[0], "man", "test singleflight shared value err")
+}
+
+type loaderCtxKey struct{}
+
+// 测试第一个调用方取消不影响回源
+func (s *LoaderTestSuite) TestLoadContext() {
+	entered := make(chan struct{})
+	release := make(chan struct{})
+	loader := func(ctx context.Context, model interface{}) error {
+		s.Equal(ctx.Value(loaderCtxKey{}), "trace", "test load ctx value err")
+		deadline, ok := ctx.Deadline()
+		s.True(ok && time.Until(deadline) <= DefaultLoadTimeout, "test load ctx timeout err")
+		close(entered)
+		<-release
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		*model.(*storeUser) = *newStoreUser()
+		return nil
+	}
+
+	first, cancel := context.WithCancel(context.WithValue(ctx, loaderCtxKey{}, "trace"))
+	done := make(chan error, 1)
+	go func() {
+		_, err := s.store.GetOrLoad(first, "user:1", new(storeUser), loader)
+		done <- err
+	}()
+	<-entered
+	cancel()
+	s.Equal(<-done, context.Canceled, "test canceled caller err")
+
+	close(release)
+	user := new(storeUser)
+	found, err := s.store.GetOrLoad(ctx, "user:1", user, loader)
+	s.Nil(err)
+	s.True(found)
+	s.Equal(user, newStoreUser(), "test load after cancel err")
+}
+
+func TestLoaderSuite(t *testing.T) {
+	suite.Run(t, new(LoaderTestSuite))
+}
@@ -0,0 +1,84 @@
+package xstore
+
+import "context"
+
+// ----------------------------------------
+// key 由模型声明的模板生成，见 xhash.Encoder.Key
+// ----------------------------------------
+
+// Key 按模型声明的模板生成 key
+func (s *Store) Key(model interface{}) (string, error) {
+	return s.encoder.Key(model)
+}
+
+// SaveModel 将模型写入由模板生成的 key
+func (s *Store) SaveModel(ctx context.Context, model interface{}) error {
+	key, err := s.Key(model)
+	if err != nil {
+		return err
+	}
+	return s.Save(ctx, key, model)
+}
+
+// UpdateModel 只将指定的 Go 字段写入由模板生成的 key
+func (s *Store) UpdateModel(ctx context.Context, model interface{}, fieldNames ...string) error {
+	key, err := s.Key(model)
+	if err != nil {
+		return err
+	}
+	return s.Update(ctx, key, model, fieldNames...)
+}
+
+// LoadModel 读取由模板生成的 key，模板中用到的字段需要先填充
+func (s *Store) LoadModel(ctx context.Context, model interface{}) (found bool, err error) {
+	key, err := s.Key(model)
+	if err != nil {
+		return false, err
+	}
+	return s.Load(ctx, key, model)
+}
+
+// LoadModelFields 用 HMGET 只读取由模板生成的 key 中模型需要的字段
+func (s *Store) LoadModelFields(ctx context.Context, model interface{}, fieldNames ...string) (found bool, err error) {
+	key, err := s.Key(model)
+	if err != nil {
+		return false, err
+	}
+	return s.LoadFields(ctx, key, model, fieldNames...)
+}
+
+// IncrModelBy 原子增减由模板生成的 key 中的整数计数字段，见 IncrBy
+func (s *Store) IncrModelBy(ctx context.Context, model interface{}, fieldName string, delta int64) error {
+	key, err := s.Key(model)
+	if err != nil {
+		return err
+	}
+	return s.IncrBy(ctx, key, model, fieldName, delta)
+}
+
+// IncrModelByFloat 原子增减由模板生成的 key 中的浮点数计数字段，见 IncrByFloat
+func (s *Store) IncrModelByFloat(ctx context.Context, model interface{}, fieldName string, delta float64) error {
+	key, err := s.Key(model)
+	if err != nil {
+		return err
+	}
+	return s.IncrByFloat(ctx, key, model, fieldName, delta)
+}
+
+// DeleteModel 删除由模板生成的 key
+func (s *Store) DeleteModel(ctx context.Context, model interface{}) error {
+	key, err := s.Key(model)
+	if err != nil {
+		return err
+	}
+	return s.Delete(ctx, key)
+}
+
+// ExistsModel 由模板生成的 key 是否存在
+func (s *Store) ExistsModel(ctx context.Context, model interface{}) (bool, error) {
+	key, err := s.Key(model)
+	if err != nil {
+		return false, err
+	}
+	return s.Exists(ctx, key)
+}
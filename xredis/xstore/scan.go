@@ -0,0 +1,81 @@
+package xstore
+
+import (
+	"github.com/redis/go-redis/v9"
+	"github.com/wanghuida/go-redis-ext/xredis/xhash"
+)
+
+// ----------------------------------------
+// 直接从命令的结果转模型，保留命令的错误并区分 key 是否存在，例:
+//
+//	found, err := xstore.ScanHash(client.HGetAll(ctx, key), user)
+//
+//	keys, err := xhash.FieldKeys(view)
+//	found, err := xstore.ScanValues(client.HMGet(ctx, key, keys...), view)
+//
+// 也可以在 pipeline 执行后使用
+// ----------------------------------------
+
+// defaultDecoder 包级别的 Scan 函数使用 xhash 的默认配置
+var defaultDecoder = xhash.NewDecoder(xhash.Options{})
+
+// ScanHash 将 HGETALL 的结果转模型，命令出错时返回该错误；
+// key 不存在时 found 为 false，模型保持不变
+func ScanHash(cmd *redis.MapStringStringCmd, model interface{}) (found bool, err error) {
+	return scanHash(defaultDecoder, cmd, model)
+}
+
+// ScanValues 将 HMGET 的结果转模型，HMGET 的字段需要与 xhash.FieldKeys(model, fieldNames...) 一致；
+// 所有字段都不存在时 found 为 false，模型保持不变
+func ScanValues(cmd *redis.SliceCmd, model interface{}, fieldNames ...string) (found bool, err error) {
+	return scanValues(defaultDecoder, cmd, model, fieldNames...)
+}
+
+// ScanHash 同包级别的 ScanHash，使用 Store 的配置
+func (s *Store) ScanHash(cmd *redis.MapStringStringCmd, model interface{}) (found bool, err error) {
+	return scanHash(s.decoder, cmd, model)
+}
+
+// ScanValues 同包级别的 ScanValues，使用 Store 的配置
+func (s *Store) ScanValues(cmd *redis.SliceCmd, model interface{}, fieldNames ...string) (found bool, err error) {
+	return scanValues(s.decoder, cmd, model, fieldNames...)
+}
+
+func scanHash(decoder *xhash.Decoder, cmd *redis.MapStringStringCmd, model interface{}) (bool, error) {
+	fields, err := cmd.Result()
+	if err != nil {
+		return false, err
+	}
+	// redis 不会保存空的 hash，没有字段即不存在；GetOrLoad 缓存的不存在标记也视为不存在
+	if len(fields) == 0 || isNotFound(fields) {
+		return false, nil
+	}
+	if err := decoder.Decode(fields, model); err != nil {
+		return true, err
+	}
+	return true, nil
+}
+
+func scanValues(decoder *xhash.Decoder, cmd *redis.SliceCmd, model interface{}, fieldNames ...string) (bool, error) {
+	values, err := cmd.Result()
+	if err != nil {
+		return false, err
+	}
+	if !hasValue(values) {
+		return false, nil
+	}
+	if err := decoder.DecodeValues(values, model, fieldNames...); err != nil {
+		return true, err
+	}
+	return true, nil
+}
+
+// hasValue HMGET 的结果中是否有存在的字段
+func hasValue(values []interface{}) bool {
+	for _, value := range values {
+		if value != nil {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,64 @@
+package xstore
+
+import (
+	"sort"
+	"strings"
+)
+
+// slotNumber Redis Cluster 的 slot 数量
+const slotNumber = 16384
+
+// hashSlot key 所在的 slot，有 hash tag 时只计算 {} 中的部分，规则同 Redis Cluster
+func hashSlot(key string) int {
+	if start := strings.IndexByte(key, '{'); start >= 0 {
+		if end := strings.IndexByte(key[start+1:], '}'); end > 0 {
+			key = key[start+1 : start+1+end]
+		}
+	}
+	return int(crc16(key)) % slotNumber
+}
+
+// crc16 CRC16-CCITT (XMODEM)，Redis Cluster 使用的校验算法
+func crc16(key string) uint16 {
+	var crc uint16
+	for i := 0; i < len(key); i++ {
+		crc ^= uint16(key[i]) << 8
+		for j := 0; j < 8; j++ {
+			if crc&0x8000 != 0 {
+				crc = crc<<1 ^ 0x1021
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+	return crc
+}
+
+// chunkKeys 将 keys 的下标按 size 分批，bySlot 为 true 时先按 slot 排序，
+// 让同一个 slot 的 key 尽量在同一批中，减少每批涉及的节点
+func chunkKeys(keys []string, size int, bySlot bool) [][]int {
+	indexes := make([]int, len(keys))
+	for i := range indexes {
+		indexes[i] = i
+	}
+	if bySlot {
+		slots := make([]int, len(keys))
+		for i, key := range keys {
+			slots[i] = hashSlot(key)
+		}
+		sort.SliceStable(indexes, func(i, j int) bool {
+			return slots[indexes[i]] < slots[indexes[j]]
+		})
+	}
+
+	var chunks [][]int
+	for len(indexes) > 0 {
+		n := size
+		if n > len(indexes) {
+			n = len(indexes)
+		}
+		chunks = append(chunks, indexes[:n])
+		indexes = indexes[n:]
+	}
+	return chunks
+}
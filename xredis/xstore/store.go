@@ -0,0 +1,182 @@
+package xstore
+
+import (
+	"context"
+	"fmt"
+	"github.com/redis/go-redis/v9"
+	"github.com/wanghuida/go-redis-ext/xredis/xhash"
+	"golang.org/x/sync/singleflight"
+	"strings"
+	"time"
+)
+
+// Options Store 的配置，零值字段使用默认配置
+type Options struct {
+	Encoder *xhash.Encoder // 模型转 map，默认使用 xhash 的默认配置
+	Decoder *xhash.Decoder // map 转模型，默认使用 xhash 的默认配置
+
+	// BatchSize 批量操作时每个 pipeline 包含的 key 数量，默认 DefaultBatchSize
+	BatchSize int
+
+	// GetOrLoad 回源后写入缓存的配置
+	LoadTTL     time.Duration // 写入的过期时间，为 0 时使用模型声明的过期时间
+	NegativeTTL time.Duration // 数据源中不存在时缓存该结果的时间，为 0 时不缓存
+	TTLJitter   time.Duration // 过期时间随机增加 [0, TTLJitter)，避免同时过期
+	LoadTimeout time.Duration // 回源及写入缓存的超时时间，默认 DefaultLoadTimeout
+}
+
+// Store 基于 hash 存取带 tag 的结构体，可以并发使用
+type Store struct {
+	client      redis.UniversalClient
+	encoder     *xhash.Encoder
+	decoder     *xhash.Decoder
+	batchSize   int
+	loadTTL     time.Duration
+	negativeTTL time.Duration
+	ttlJitter   time.Duration
+	loadTimeout time.Duration
+	loading     singleflight.Group // 合并同一个 key 并发的回源
+}
+
+// NewStore 创建 Store，client 可以是单机、哨兵或集群客户端
+func NewStore(client redis.UniversalClient, opts Options) *Store {
+	if opts.Encoder == nil {
+		opts.Encoder = xhash.NewEncoder(xhash.Options{})
+	}
+	if opts.Decoder == nil {
+		opts.Decoder = xhash.NewDecoder(xhash.Options{})
+	}
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = DefaultBatchSize
+	}
+	if opts.LoadTimeout <= 0 {
+		opts.LoadTimeout = DefaultLoadTimeout
+	}
+	return &Store{
+		client:      client,
+		encoder:     opts.Encoder,
+		decoder:     opts.Decoder,
+		batchSize:   opts.BatchSize,
+		loadTTL:     opts.LoadTTL,
+		negativeTTL: opts.NegativeTTL,
+		ttlJitter:   opts.TTLJitter,
+		loadTimeout: opts.LoadTimeout,
+	}
+}
+
+// Client 底层的 redis 客户端
+func (s *Store) Client() redis.UniversalClient {
+	return s.client
+}
+
+// Save 将模型的所有字段写入 hash，hash 中已有的其他字段保留
+// Encoder 配置了 xhash.NilDelete 时，值为 nil 的字段会在同一个事务中删除
+func (s *Store) Save(ctx context.Context, key string, model interface{}) error {
+	return s.Update(ctx, key, model)
+}
+
+// Update 只将指定的 Go 字段写入 hash，没有指定字段时与 Save 相同
+func (s *Store) Update(ctx context.Context, key string, model interface{}, fieldNames ...string) error {
+	changes, err := s.encoder.EncodeChanges(model, fieldNames...)
+	if err != nil {
+		return err
+	}
+	exp, err := s.expiration(model)
+	if err != nil {
+		return err
+	}
+	return s.write(ctx, key, model, changes, exp)
+}
+
+// Load 读取 hash 填充到模型，key 不存在时 found 为 false，模型保持不变
+func (s *Store) Load(ctx context.Context, key string, model interface{}) (found bool, err error) {
+	return s.ScanHash(s.client.HGetAll(ctx, key), model)
+}
+
+// LoadFields 用 HMGET 只读取模型需要的字段，fieldNames 为 Go 字段名，为空时读取模型的所有字段
+// 适合在大的 hash 上定义小的视图结构体；所有字段都不存在时 found 为 false，模型保持不变
+func (s *Store) LoadFields(ctx context.Context, key string, model interface{}, fieldNames ...string) (found bool, err error) {
+	keys, err := s.decoder.FieldKeys(model, fieldNames...)
+	if err != nil {
+		return false, err
+	}
+	if len(keys) == 0 {
+		return false, nil
+	}
+	return s.ScanValues(s.client.HMGet(ctx, key, keys...), model, fieldNames...)
+}
+
+// Delete 删除 hash
+func (s *Store) Delete(ctx context.Context, keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	return s.client.Del(ctx, keys...).Err()
+}
+
+// Exists hash 是否存在，配置了 NegativeTTL 时只有不存在标记的 hash 视为不存在，与 GetOrLoad 一致
+func (s *Store) Exists(ctx context.Context, key string) (bool, error) {
+	if s.negativeTTL > 0 {
+		count, err := existsScript.Run(ctx, s.client, []string{key}, NotFoundField).Int64()
+		if err != nil {
+			return false, err
+		}
+		return count > 0, nil
+	}
+	count, err := s.client.Exists(ctx, key).Result()
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// write 写入及删除字段并设置过期时间，多个命令时在一个事务中执行；HSET / HDEL 不允许空的字段列表
+// 模型的版本字段不会覆盖 hash 中已有的版本，见 takeVersion；配置了 NegativeTTL 时 GetOrLoad 写入的不存在标记会一起删除
+func (s *Store) write(ctx context.Context, key string, model interface{}, changes *xhash.Changes, exp *xhash.Expiration) error {
+	version, err := s.takeVersion(model, changes)
+	if err != nil {
+		return err
+	}
+	if changes.Empty() {
+		return nil
+	}
+	if exp.Empty() && version == nil && s.negativeTTL <= 0 {
+		switch {
+		case len(changes.Del) == 0:
+			return s.client.HSet(ctx, key, changes.Set).Err()
+		case len(changes.Set) == 0:
+			return s.client.HDel(ctx, key, changes.Del...).Err()
+		}
+	}
+	_, err = s.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		s.queueWrite(ctx, pipe, key, changes, exp, version)
+		return nil
+	})
+	return writeError(err, changes, exp)
+}
+
+// writeError 转换写入事务的错误；服务端不认识的命令会让整个事务被放弃，返回 EXECABORT，其他命令入队时不会出错
+func writeError(err error, changes *xhash.Changes, exp *xhash.Expiration) error {
+	if err != nil && strings.HasPrefix(err.Error(), "EXECABORT") && len(fieldTTLGroups(changes, exp)) > 0 {
+		return fmt.Errorf("%w: %v", ErrFieldTTLUnsupported, err)
+	}
+	return ttlError(err)
+}
+
+// queueWrite 将写入的命令加入 pipeline，返回加入的命令；先删除不存在的标记，见 clearNotFound
+func (s *Store) queueWrite(ctx context.Context, pipe redis.Pipeliner, key string, changes *xhash.Changes, exp *xhash.Expiration, version *versionInit) []redis.Cmder {
+	var cmds []redis.Cmder
+	if cmd := s.clearNotFound(ctx, pipe, key); cmd != nil {
+		cmds = append(cmds, cmd)
+	}
+	if version != nil {
+		cmds = append(cmds, pipe.HSetNX(ctx, key, version.key, version.value))
+	}
+	if len(changes.Set) > 0 {
+		cmds = append(cmds, pipe.HSet(ctx, key, changes.Set))
+	}
+	if len(changes.Del) > 0 {
+		cmds = append(cmds, pipe.HDel(ctx, key, changes.Del...))
+	}
+	return append(cmds, expire(ctx, pipe, key, changes, exp)...)
+}
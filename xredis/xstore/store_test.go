@@ -0,0 +1,456 @@
+package xstore
+
+import (
+	"context"
+	"errors"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/suite"
+	"github.com/wanghuida/go-redis-ext/xredis/xhash"
+	"math"
+	"testing"
+	"time"
+)
+
+var ctx = context.Background()
+
+type storeUser struct {
+	Id        int64
+	Name      string
+	Tags      []string
+	Score     float64
+	CreatedAt time.Time
+}
+
+type StoreTestSuite struct {
+	suite.Suite
+	server *miniredis.Miniredis
+	store  *Store
+}
+
+// newTestStore 启动 miniredis 并创建 Store，测试结束时自动关闭
+func newTestStore(t *testing.T, opts Options) (*miniredis.Miniredis, *Store) {
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() {
+		client.Close()
+	})
+	return server, NewStore(client, opts)
+}
+
+func (s *StoreTestSuite) SetupTest() {
+	s.server, s.store = newTestStore(s.T(), Options{})
+}
+
+func newStoreUser() *storeUser {
+	return &storeUser{
+		Id:        1,
+		Name:      "william",
+		Tags:      []string{"man", "pupil"},
+		Score:     3.1415,
+		CreatedAt: time.Date(2019, 5, 23, 10, 20, 30, 0, time.Local),
+	}
+}
+
+// 测试写入与读取
+func (s *StoreTestSuite) TestSaveLoad() {
+	err := s.store.Save(ctx, "user:1", newStoreUser())
+	s.Nil(err)
+	s.Equal(s.server.HGet("user:1", "name"), "william", "test save value err")
+
+	result := new(storeUser)
+	found, err := s.store.Load(ctx, "user:1", result)
+	s.Nil(err)
+	s.True(found, "test load found err")
+	s.Equal(result, newStoreUser(), "test load value err")
+}
+
+// 测试 key 不存在
+func (s *StoreTestSuite) TestLoadMissing() {
+	result := &storeUser{Name: "keep"}
+	found, err := s.store.Load(ctx, "user:404", result)
+	s.Nil(err)
+	s.False(found, "test load missing found err")
+	s.Equal(result.Name, "keep", "test load missing should not touch model")
+}
+
+// 测试只更新部分字段
+func (s *StoreTestSuite) TestUpdate() {
+	s.Nil(s.store.Save(ctx, "user:1", newStoreUser()))
+
+	user := &storeUser{Id: 2, Name: "wade", Score: 100}
+	err := s.store.Update(ctx, "user:1", user, "Score")
+	s.Nil(err)
+	s.Equal(s.server.HGet("user:1", "score"), "100", "test update value err")
+	s.Equal(s.server.HGet("user:1", "name"), "william", "test update other field err")
+
+	err = s.store.Update(ctx, "user:1", user, "Unknown")
+	s.NotNil(err, "test update unknown field err")
+}
+
+type storeUserView struct {
+	Name  string
+	Score float64
+}
+
+// 测试只读取部分字段
+func (s *StoreTestSuite) TestLoadFields() {
+	s.Nil(s.store.Save(ctx, "user:1", newStoreUser()))
+
+	view := new(storeUserView)
+	found, err := s.store.LoadFields(ctx, "user:1", view)
+	s.Nil(err)
+	s.True(found, "test load fields found err")
+	s.Equal(view, &storeUserView{Name: "william", Score: 3.1415}, "test load fields value err")
+
+	user := new(storeUser)
+	found, err = s.store.LoadFields(ctx, "user:1", user, "Id", "Tags")
+	s.Nil(err)
+	s.True(found, "test load named fields found err")
+	s.Equal(user, &storeUser{Id: 1, Tags: []string{"man", "pupil"}}, "test load named fields value err")
+
+	found, err = s.store.LoadFields(ctx, "user:404", view)
+	s.Nil(err)
+	s.False(found, "test load fields missing err")
+}
+
+// 测试只写入变化的字段
+func (s *StoreTestSuite) TestSaveChanges() {
+	type tracked struct {
+		Id       int64
+		Score    int
+		Nickname *string
+	}
+	nickname := "will"
+	s.Nil(s.store.Save(ctx, "user:1", &tracked{Id: 1, Score: 10, Nickname: &nickname}))
+
+	user := new(tracked)
+	_, err := s.store.Load(ctx, "user:1", user)
+	s.Nil(err)
+	snapshot, err := s.store.Snapshot(user)
+	s.Nil(err)
+
+	// 其他客户端修改了 id，不应该被覆盖
+	s.server.HSet("user:1", "id", "2")
+
+	user.Score = 11
+	user.Nickname = nil
+	s.Nil(s.store.SaveChanges(ctx, "user:1", user, snapshot))
+	s.Equal(s.server.HGet("user:1", "score"), "11", "test save changes value err")
+	s.Equal(s.server.HGet("user:1", "id"), "2", "test save changes should not touch other fields")
+	keys, _ := s.server.HKeys("user:1")
+	s.NotContains(keys, "nickname", "test save changes del err")
+
+	// snapshot 已经更新，再次保存没有变化
+	s.server.HSet("user:1", "score", "100")
+	s.Nil(s.store.SaveChanges(ctx, "user:1", user, snapshot))
+	s.Equal(s.server.HGet("user:1", "score"), "100", "test save no changes err")
+}
+
+// 测试 nil 指针的处理
+func (s *StoreTestSuite) TestNilPolicy() {
+	type model struct {
+		Id       int64
+		Nickname *string
+	}
+	opts := xhash.Options{NilPolicy: xhash.NilDelete}
+	store := NewStore(s.store.Client(), Options{Encoder: xhash.NewEncoder(opts), Decoder: xhash.NewDecoder(opts)})
+
+	nickname := "will"
+	s.Nil(store.Save(ctx, "user:1", &model{Id: 1, Nickname: &nickname}))
+	s.Equal(s.server.HGet("user:1", "nickname"), "will", "test nil policy save err")
+
+	// 变为 nil 后删除，读取时仍然为 nil
+	s.Nil(store.Save(ctx, "user:1", &model{Id: 1}))
+	keys, _ := s.server.HKeys("user:1")
+	s.Equal(keys, []string{"id"}, "test nil delete err")
+
+	result := new(model)
+	_, err := store.Load(ctx, "user:1", result)
+	s.Nil(err)
+	s.Nil(result.Nickname, "test nil round trip err")
+
+	// 标记的方式
+	opts = xhash.Options{NilPolicy: xhash.NilMarker}
+	store = NewStore(s.store.Client(), Options{Encoder: xhash.NewEncoder(opts), Decoder: xhash.NewDecoder(opts)})
+	s.Nil(store.Save(ctx, "user:2", &model{Id: 2}))
+	result = &model{Nickname: &nickname}
+	_, err = store.Load(ctx, "user:2", result)
+	s.Nil(err)
+	s.Nil(result.Nickname, "test nil marker round trip err")
+}
+
+// 测试删除与是否存在
+func (s *StoreTestSuite) TestDeleteExists() {
+	s.Nil(s.store.Save(ctx, "user:1", newStoreUser()))
+
+	exists, err := s.store.Exists(ctx, "user:1")
+	s.Nil(err)
+	s.True(exists, "test exists err")
+
+	s.Nil(s.store.Delete(ctx, "user:1"))
+	exists, err = s.store.Exists(ctx, "user:1")
+	s.Nil(err)
+	s.False(exists, "test delete err")
+}
+
+// 测试读取失败
+func (s *StoreTestSuite) TestLoadDecodeError() {
+	s.server.HSet("user:1", "id", "abc")
+	found, err := s.store.Load(ctx, "user:1", new(storeUser))
+	s.True(found, "test load decode err found")
+	s.NotNil(err, "test load decode err")
+}
+
+type storeOrder struct {
+	_      struct{} `redis:"key=order:{{shop_id}}:{id}"`
+	Id     int64
+	ShopId int64
+	Amount float64
+}
+
+// 测试由模型生成 key
+func (s *StoreTestSuite) TestModelKey() {
+	order := &storeOrder{Id: 7, ShopId: 42, Amount: 9.9}
+	s.Nil(s.store.SaveModel(ctx, order))
+	s.Equal(s.server.HGet("order:{42}:7", "amount"), "9.9", "test save model err")
+
+	order.Amount = 19.9
+	s.Nil(s.store.UpdateModel(ctx, order, "Amount"))
+	s.Equal(s.server.HGet("order:{42}:7", "amount"), "19.9", "test update model err")
+
+	result := &storeOrder{Id: 7, ShopId: 42}
+	found, err := s.store.LoadModel(ctx, result)
+	s.Nil(err)
+	s.True(found, "test load model found err")
+	s.Equal(result.Amount, 19.9, "test load model value err")
+
+	exists, err := s.store.ExistsModel(ctx, result)
+	s.Nil(err)
+	s.True(exists, "test exists model err")
+
+	s.Nil(s.store.DeleteModel(ctx, result))
+	s.False(s.server.Exists("order:{42}:7"), "test delete model err")
+
+	_, err = s.store.LoadModel(ctx, newStoreUser())
+	s.NotNil(err, "test model without key err")
+}
+
+type versionUser struct {
+	Id      int64
+	Name    string
+	Version int64 `redis:"version;version"`
+}
+
+// 测试乐观锁
+func (s *StoreTestSuite) TestSaveVersioned() {
+	user := &versionUser{Id: 1, Name: "william"}
+	s.Nil(s.store.SaveVersioned(ctx, "user:1", user))
+	s.Equal(user.Version, int64(1), "test save versioned version err")
+	s.Equal(s.server.HGet("user:1", "version"), "1")
+
+	// 另一个客户端读取到相同的版本
+	other := new(versionUser)
+	_, err := s.store.Load(ctx, "user:1", other)
+	s.Nil(err)
+
+	user.Name = "wade"
+	s.Nil(s.store.SaveVersioned(ctx, "user:1", user, "Name"))
+	s.Equal(user.Version, int64(2))
+
+	other.Name = "james"
+	err = s.store.SaveVersioned(ctx, "user:1", other)
+	s.True(errors.Is(err, ErrConflict), "test conflict err")
+	conflict, ok := err.(*ConflictError)
+	s.True(ok)
+	s.Equal(conflict.Expected, int64(1))
+	s.Equal(conflict.Actual, int64(2))
+	s.Equal(s.server.HGet("user:1", "name"), "wade", "test conflict should not write")
+
+	// 重新读取后重试
+	_, err = s.store.Load(ctx, "user:1", other)
+	s.Nil(err)
+	other.Name = "james"
+	s.Nil(s.store.SaveVersioned(ctx, "user:1", other))
+	s.Equal(s.server.HGet("user:1", "name"), "james")
+	s.Equal(s.server.HGet("user:1", "version"), "3")
+
+	s.Equal(s.store.SaveVersioned(ctx, "user:2", newStoreUser()), xhash.ErrNoVersion)
+}
+
+// 测试普通写入不会回退 hash 中的版本，旧的模型之后的 SaveVersioned 仍然冲突
+func (s *StoreTestSuite) TestSaveKeepsVersion() {
+	user := &versionUser{Id: 1, Name: "william"}
+	s.Nil(s.store.SaveVersioned(ctx, "user:1", user))
+	stale := *user
+	user.Name = "wade"
+	s.Nil(s.store.SaveVersioned(ctx, "user:1", user))
+	s.Equal(s.server.HGet("user:1", "version"), "2")
+
+	stale.Name = "james"
+	s.Nil(s.store.Save(ctx, "user:1", &stale))
+	s.Equal(s.server.HGet("user:1", "version"), "2", "test save should not roll back version")
+	_, err := SaveMany(ctx, s.store, []string{"user:1"}, []*versionUser{&stale})
+	s.Nil(err)
+	s.Equal(s.server.HGet("user:1", "version"), "2", "test save many should not roll back version")
+
+	err = s.store.SaveVersioned(ctx, "user:1", &stale)
+	s.True(errors.Is(err, ErrConflict), "test stale save versioned should conflict err=%v", err)
+
+	// hash 中没有版本时，普通写入会初始化版本
+	s.Nil(s.store.Save(ctx, "user:2", &versionUser{Id: 2, Version: 5}))
+	s.Equal(s.server.HGet("user:2", "version"), "5")
+}
+
+// 测试计数字段
+func (s *StoreTestSuite) TestIncr() {
+	s.Nil(s.store.Save(ctx, "user:1", newStoreUser()))
+
+	// 没有过期时间且没有配置 NegativeTTL 时只发送一个命令
+	count := s.server.CommandCount()
+	user := new(storeUser)
+	s.Nil(s.store.IncrByFloat(ctx, "user:1", user, "Score", 1.5))
+	s.Equal(s.server.CommandCount()-count, 1, "test incr command count err")
+	s.Equal(user.Score, 4.6415, "test incr float err")
+	s.Equal(user.Name, "", "test incr should only fill the field")
+
+	s.Nil(s.store.IncrFieldBy(ctx, "user:1", user, &user.Id, 2))
+	s.Equal(user.Id, int64(3), "test incr int err")
+	s.Equal(s.server.HGet("user:1", "id"), "3")
+
+	// 超过 2^53 的整数不会丢失精度
+	s.Nil(s.store.IncrBy(ctx, "user:1", user, "Id", 1<<53+1))
+	s.Equal(user.Id, int64(1<<53+4), "test incr large int err")
+	s.Nil(s.store.IncrFieldBy(ctx, "user:1", user, &user.Id, -(1<<53 + 4)))
+	s.Nil(s.store.IncrBy(ctx, "user:1", user, "Id", math.MaxInt64))
+	s.Equal(user.Id, int64(math.MaxInt64), "test incr max int err")
+
+	s.Nil(s.store.IncrFieldByFloat(ctx, "user:1", user, &user.Score, -0.5))
+	s.Equal(user.Score, 4.1415, "test incr field float err")
+
+	s.NotNil(s.store.IncrByFloat(ctx, "user:1", user, "Id", 0.5), "test incr int with float err")
+	s.NotNil(s.store.IncrBy(ctx, "user:1", user, "Score", 1), "test incr float with int err")
+	s.NotNil(s.store.IncrBy(ctx, "user:1", user, "Name", 1), "test incr string err")
+}
+
+type ttlSession struct {
+	_       struct{} `redis:"ttl=1h"`
+	Token   string
+	Version int64 `redis:"version;version"`
+}
+
+type ttlShortSession struct {
+	_     struct{} `redis:"ttl=1500ms"`
+	Token string
+}
+
+type ttlCounter struct {
+	_    struct{} `redis:"ttl=1h"`
+	Hits int64
+}
+
+type ttlCodeSession struct {
+	Token string
+	Code  string `redis:"code;ttl=5m"`
+}
+
+// 测试过期时间
+func (s *StoreTestSuite) TestTTL() {
+	s.Nil(s.store.Save(ctx, "session:1", &ttlSession{Token: "abc"}))
+	s.Equal(s.server.TTL("session:1"), time.Hour, "test model ttl err")
+
+	s.Nil(s.store.Save(ctx, "session:2", &ttlShortSession{Token: "abc"}))
+	s.Equal(s.server.TTL("session:2"), 1500*time.Millisecond, "test model pexpire err")
+
+	s.Nil(s.store.SaveVersioned(ctx, "session:3", &ttlSession{Token: "abc"}))
+	s.Equal(s.server.TTL("session:3"), time.Hour, "test versioned ttl err")
+
+	results, err := SaveMany(ctx, s.store, []string{"session:4"}, []*ttlSession{{Token: "abc"}})
+	s.Nil(err)
+	s.Nil(results[0].Err)
+	s.Equal(s.server.TTL("session:4"), time.Hour, "test batch ttl err")
+
+	counter := new(ttlCounter)
+	s.Nil(s.store.IncrBy(ctx, "counter:1", counter, "Hits", 1))
+	s.Equal(counter.Hits, int64(1))
+	s.Equal(s.server.TTL("counter:1"), time.Hour, "test incr ttl err")
+
+	// 没有过期时间且没有配置 NegativeTTL 时只发送一个命令
+	count := s.server.CommandCount()
+	s.Nil(s.store.Save(ctx, "user:1", newStoreUser()))
+	s.Equal(s.server.CommandCount()-count, 1, "test save command count err")
+}
+
+// 测试不支持 hash 字段过期时间的服务端
+func (s *StoreTestSuite) TestFieldTTLUnsupported() {
+	// 没有写入带过期时间的字段时不需要设置
+	s.Nil(s.store.Update(ctx, "session:1", &ttlCodeSession{Token: "abc"}, "Token"))
+
+	type versionedCode struct {
+		Code    string `redis:"code;ttl=5m"`
+		Version int64  `redis:"version;version"`
+	}
+	err := s.store.SaveVersioned(ctx, "session:2", &versionedCode{Code: "123"})
+	s.True(errors.Is(err, ErrFieldTTLUnsupported), "test versioned field ttl unsupported err=%v", err)
+	s.False(s.server.Exists("session:2"), "test versioned field ttl unsupported should not write")
+}
+
+// 测试识别不支持 hash 字段过期时间的错误，事务中的情况 miniredis 与 Redis 的行为不一致，无法在 suite 中测试
+func TestTTLError(t *testing.T) {
+	err := ttlError(errors.New("ERR unknown command 'HPEXPIRE', with args beginning with: 'session:1'"))
+	if !errors.Is(err, ErrFieldTTLUnsupported) {
+		t.Errorf("test unknown hpexpire err=%v", err)
+	}
+	err = ttlError(errors.New("ERR unknown command 'HSETEX'"))
+	if errors.Is(err, ErrFieldTTLUnsupported) {
+		t.Errorf("test other unknown command err=%v", err)
+	}
+	if ttlError(nil) != nil {
+		t.Errorf("test nil err")
+	}
+
+	changes := &xhash.Changes{Set: map[string]interface{}{"a": 1, "b": 2, "c": 3}}
+	exp := &xhash.Expiration{Fields: map[string]time.Duration{"a": time.Minute, "c": time.Minute, "b": time.Second, "d": time.Second}}
+	groups := fieldTTLGroups(changes, exp)
+	if len(groups) != 2 || groups[0].ttl != time.Second || len(groups[1].fields) != 2 || groups[1].fields[1] != "c" {
+		t.Errorf("test field ttl groups err groups=%v", groups)
+	}
+}
+
+// 测试直接从命令的结果转模型
+func (s *StoreTestSuite) TestScan() {
+	s.Nil(s.store.Save(ctx, "user:1", newStoreUser()))
+	client := s.store.Client()
+
+	user := new(storeUser)
+	found, err := ScanHash(client.HGetAll(ctx, "user:1"), user)
+	s.Nil(err)
+	s.True(found)
+	s.Equal(user, newStoreUser(), "test scan hash value err")
+
+	found, err = ScanHash(client.HGetAll(ctx, "user:404"), user)
+	s.Nil(err)
+	s.False(found, "test scan hash missing err")
+
+	// 命令的错误不会被当成不存在
+	s.server.Set("user:2", "not a hash")
+	found, err = ScanHash(client.HGetAll(ctx, "user:2"), user)
+	s.NotNil(err, "test scan hash command err")
+	s.False(found)
+
+	view := new(storeUserView)
+	keys, err := xhash.FieldKeys(view)
+	s.Nil(err)
+	found, err = ScanValues(client.HMGet(ctx, "user:1", keys...), view)
+	s.Nil(err)
+	s.True(found)
+	s.Equal(view.Name, "william", "test scan values err")
+
+	found, err = s.store.ScanValues(client.HMGet(ctx, "user:404", keys...), view)
+	s.Nil(err)
+	s.False(found, "test scan values missing err")
+}
+
+func TestStoreSuite(t *testing.T) {
+	suite.Run(t, new(StoreTestSuite))
+}
@@ -0,0 +1,95 @@
+package xstore
+
+import (
+	"context"
+	"fmt"
+	"github.com/pkg/errors"
+	"github.com/redis/go-redis/v9"
+	"github.com/wanghuida/go-redis-ext/xredis/xhash"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ----------------------------------------
+// 过期时间，在模型上声明，写入时在同一个事务中设置，例:
+//
+//	type Session struct {
+//		_     struct{} `redis:"ttl=24h"`       // 整个 hash 的过期时间，使用 EXPIRE / PEXPIRE
+//		Token string
+//		Code  string `redis:"code;ttl=5m"`    // hash 字段的过期时间，使用 HEXPIRE / HPEXPIRE
+//	}
+//
+// hash 字段被重新写入后过期时间会被清除，所以每次写入该字段都会重新设置
+// ----------------------------------------
+
+// ErrFieldTTLUnsupported 服务端不支持 hash 字段的过期时间，需要 Redis 7.4 及以上
+var ErrFieldTTLUnsupported = errors.New("hash field expiration requires redis 7.4 or later")
+
+// expiration 模型声明的过期时间
+func (s *Store) expiration(model interface{}) (*xhash.Expiration, error) {
+	return s.encoder.Expiration(model)
+}
+
+// expire 在 pipeline 中设置过期时间，只有写入的字段会设置字段的过期时间
+func expire(ctx context.Context, pipe redis.Pipeliner, key string, changes *xhash.Changes, exp *xhash.Expiration) []redis.Cmder {
+	var cmds []redis.Cmder
+	for _, group := range fieldTTLGroups(changes, exp) {
+		if group.ttl%time.Second == 0 {
+			cmds = append(cmds, pipe.HExpire(ctx, key, group.ttl, group.fields...))
+		} else {
+			cmds = append(cmds, pipe.HPExpire(ctx, key, group.ttl, group.fields...))
+		}
+	}
+	switch {
+	case exp.TTL == 0:
+	case exp.TTL%time.Second == 0:
+		cmds = append(cmds, pipe.Expire(ctx, key, exp.TTL))
+	default:
+		cmds = append(cmds, pipe.PExpire(ctx, key, exp.TTL))
+	}
+	return cmds
+}
+
+// fieldTTLGroup 过期时间相同的字段，可以在一个命令中设置
+type fieldTTLGroup struct {
+	ttl    time.Duration
+	fields []string
+}
+
+// fieldTTLGroups 写入的字段中声明了过期时间的，按过期时间分组
+func fieldTTLGroups(changes *xhash.Changes, exp *xhash.Expiration) []fieldTTLGroup {
+	if len(exp.Fields) == 0 {
+		return nil
+	}
+	byTTL := make(map[time.Duration][]string)
+	for field, ttl := range exp.Fields {
+		if _, ok := changes.Set[field]; ok {
+			byTTL[ttl] = append(byTTL[ttl], field)
+		}
+	}
+	groups := make([]fieldTTLGroup, 0, len(byTTL))
+	for ttl, fields := range byTTL {
+		sort.Strings(fields)
+		groups = append(groups, fieldTTLGroup{ttl: ttl, fields: fields})
+	}
+	sort.Slice(groups, func(i, j int) bool {
+		return groups[i].ttl < groups[j].ttl
+	})
+	return groups
+}
+
+// ttlError 服务端不认识 hash 字段过期的命令时，转换成 ErrFieldTTLUnsupported
+func ttlError(err error) error {
+	if err == nil {
+		return nil
+	}
+	msg := strings.ToLower(err.Error())
+	if !strings.Contains(msg, "unknown command") {
+		return err
+	}
+	if strings.Contains(msg, "hexpire") || strings.Contains(msg, "hpexpire") || strings.Contains(msg, "hpttl") {
+		return fmt.Errorf("%w: %v", ErrFieldTTLUnsupported, err)
+	}
+	return err
+}
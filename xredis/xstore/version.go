@@ -0,0 +1,211 @@
+package xstore
+
+import (
+	"context"
+	"fmt"
+	"github.com/pkg/errors"
+	"github.com/redis/go-redis/v9"
+	"github.com/wanghuida/go-redis-ext/xredis/xhash"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// ----------------------------------------
+// 乐观锁，模型中带 version 选项的整数字段记录读取时的版本，例:
+//
+//	type User struct {
+//		Id      int64
+//		Version int64 `redis:"version;version"`
+//	}
+//
+//	found, err := store.Load(ctx, key, user)
+//	user.Score++
+//	err = store.SaveVersioned(ctx, key, user)
+//	if errors.Is(err, xstore.ErrConflict) {
+//		// 重新读取后重试
+//	}
+// ----------------------------------------
+
+// ErrConflict hash 中的版本与模型的版本不一致，其他客户端已经写入过
+var ErrConflict = errors.New("version conflict")
+
+// ConflictError 版本冲突的详细信息，支持 errors.Is(err, ErrConflict)
+type ConflictError struct {
+	Key      string
+	Expected int64 // 模型中的版本
+	Actual   int64 // hash 中的版本，字段不存在时为 0
+}
+
+func (e *ConflictError) Error() string {
+	return fmt.Sprintf("version conflict key=%s expected=%d actual=%d", e.Key, e.Expected, e.Actual)
+}
+
+// Unwrap 支持 errors.Is
+func (e *ConflictError) Unwrap() error {
+	return ErrConflict
+}
+
+// Cause 支持 github.com/pkg/errors 的 errors.Cause
+func (e *ConflictError) Cause() error {
+	return ErrConflict
+}
+
+// casScript 比较版本一致后写入及删除字段，设置过期时间，并将版本加一
+// KEYS[1] hash 的 key
+// ARGV[1] 版本字段，ARGV[2] 期望的版本，ARGV[3] 整个 hash 的过期毫秒数，0 为不设置
+// ARGV[4] 写入的字段数量 n，ARGV[5] 删除的字段数量 m，ARGV[6] 不存在的标记字段，为空时不处理，
+// 之后依次是 n 对字段与值、m 个字段、字段与过期毫秒数
+// 成功返回 {1, 新版本}，冲突返回 {0, 当前版本}；需要设置字段过期时间时先确认服务端支持，避免写入一半
+// 写入前删除不存在的标记及其过期时间，见 clearNotFoundScript
+var casScript = redis.NewScript(`
+local setEnd = 6 + tonumber(ARGV[4]) * 2
+local delEnd = setEnd + tonumber(ARGV[5])
+if #ARGV > delEnd then
+	local probe = redis.pcall('HPTTL', KEYS[1], 'FIELDS', 1, ARGV[delEnd + 1])
+	if not probe then
+		return redis.error_reply('unknown command HPTTL')
+	end
+	if probe.err then
+		return probe
+	end
+end
+local current = redis.call('HGET', KEYS[1], ARGV[1]) or '0'
+if current ~= ARGV[2] then
+	return {0, current}
+end
+if ARGV[6] ~= '' and redis.call('HDEL', KEYS[1], ARGV[6]) == 1 then
+	redis.call('PERSIST', KEYS[1])
+end
+if setEnd > 6 then
+	redis.call('HMSET', KEYS[1], unpack(ARGV, 7, setEnd))
+end
+if delEnd > setEnd then
+	redis.call('HDEL', KEYS[1], unpack(ARGV, setEnd + 1, delEnd))
+end
+for i = delEnd + 1, #ARGV, 2 do
+	redis.call('HPEXPIRE', KEYS[1], ARGV[i + 1], 'FIELDS', 1, ARGV[i])
+end
+if ARGV[3] ~= '0' then
+	redis.call('PEXPIRE', KEYS[1], ARGV[3])
+end
+return {1, redis.call('HINCRBY', KEYS[1], ARGV[1], 1)}
+`)
+
+// SaveVersioned hash 中的版本与模型的版本一致时才写入，同时版本加一，并更新到模型中；
+// 不一致时返回 *ConflictError；fieldNames 为空时写入所有字段，否则只写入指定的 Go 字段；
+// hash 中没有版本字段时视为 0，新的模型版本为 0 即可创建；模型声明的过期时间在脚本中一起设置
+func (s *Store) SaveVersioned(ctx context.Context, key string, model interface{}, fieldNames ...string) error {
+	versionKey, version, err := s.encoder.Version(model)
+	if err != nil {
+		return err
+	}
+	changes, err := s.encoder.EncodeChanges(model, fieldNames...)
+	if err != nil {
+		return err
+	}
+	exp, err := s.expiration(model)
+	if err != nil {
+		return err
+	}
+
+	// 版本字段由脚本维护
+	if _, err := s.takeVersion(model, changes); err != nil {
+		return err
+	}
+	names := make([]string, 0, len(changes.Set))
+	for name := range changes.Set {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	dels := changes.Del
+
+	args := make([]interface{}, 0, 6+len(names)*2+len(dels))
+	args = append(args, versionKey, strconv.FormatInt(version, 10), int64(exp.TTL/time.Millisecond), len(names), len(dels), s.notFoundMarker())
+	for _, name := range names {
+		args = append(args, name, changes.Set[name])
+	}
+	for _, name := range dels {
+		args = append(args, name)
+	}
+	for _, group := range fieldTTLGroups(changes, exp) {
+		for _, field := range group.fields {
+			args = append(args, field, int64(group.ttl/time.Millisecond))
+		}
+	}
+
+	result, err := casScript.Run(ctx, s.client, []string{key}, args...).Result()
+	if err != nil {
+		return ttlError(err)
+	}
+	ok, current, err := parseCASResult(result)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return &ConflictError{Key: key, Expected: version, Actual: current}
+	}
+	return s.decoder.SetVersion(model, current)
+}
+
+// SaveModelVersioned 同 SaveVersioned，key 由模型声明的模板生成
+func (s *Store) SaveModelVersioned(ctx context.Context, model interface{}, fieldNames ...string) error {
+	key, err := s.Key(model)
+	if err != nil {
+		return err
+	}
+	return s.SaveVersioned(ctx, key, model, fieldNames...)
+}
+
+// versionInit 普通写入时从变化中取出的版本字段，hash 中没有版本时才用 HSETNX 写入
+type versionInit struct {
+	key   string
+	value interface{}
+}
+
+// takeVersion 版本只由 SaveVersioned 维护，普通写入时从 changes 中去掉版本字段，
+// 避免旧的模型把 hash 中的版本改回去，让之后的 SaveVersioned 误判为没有冲突；模型没有版本字段时返回 nil
+func (s *Store) takeVersion(model interface{}, changes *xhash.Changes) (*versionInit, error) {
+	versionKey, _, err := s.encoder.Version(model)
+	if err == xhash.ErrNoVersion {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	dels := changes.Del[:0]
+	for _, name := range changes.Del {
+		if name != versionKey {
+			dels = append(dels, name)
+		}
+	}
+	changes.Del = dels
+	value, ok := changes.Set[versionKey]
+	if !ok {
+		return nil, nil
+	}
+	delete(changes.Set, versionKey)
+	return &versionInit{key: versionKey, value: value}, nil
+}
+
+// parseCASResult 解析脚本的返回值
+func parseCASResult(result interface{}) (ok bool, version int64, err error) {
+	values, isSlice := result.([]interface{})
+	if !isSlice || len(values) != 2 {
+		errMsg := fmt.Sprintf("unexpected cas result=%v", result)
+		return false, 0, errors.New(errMsg)
+	}
+	switch v := values[1].(type) {
+	case int64:
+		version = v
+	case string:
+		version, err = strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return false, 0, errors.Wrapf(err, "invalid stored version value=%q", v)
+		}
+	default:
+		errMsg := fmt.Sprintf("unexpected cas result=%v", result)
+		return false, 0, errors.New(errMsg)
+	}
+	return values[0] == int64(1), version, nil
+}
@@ -3,4 +3,5 @@ package xredis
 import (
 	// 由于分了 package 所以在根目录添加了引用
 	_ "github.com/wanghuida/go-redis-ext/xredis/xhash"
+	_ "github.com/wanghuida/go-redis-ext/xredis/xstore"
 )